@@ -0,0 +1,205 @@
+/*
+The datagen command generates test data from a JSON specification. The
+specification describes a record and its fields and the way the values of
+each field should be generated. For instance:
+
+	{
+	    "seed": 42,
+	    "record": {
+	        "name": "orders",
+	        "fields": [
+	            {"name": "id", "kind": "incrementing", "initial": 1, "incr": 1},
+	            {"name": "when", "kind": "time",
+	             "start": "2024-01-01T09:00:00Z", "interval": "90s"},
+	            {"name": "status", "kind": "weightedStrings",
+	             "values": [{"str": "open", "weight": 3},
+	                        {"str": "closed", "weight": 1}]},
+	            {"name": "qty", "kind": "normal", "type": "int",
+	             "min": 1, "max": 100, "mean": 10, "sd": 5},
+	            {"name": "price", "kind": "money", "country": "GB",
+	             "amount": {"kind": "normal", "min": 100, "max": 100000,
+	                        "mean": 2000, "sd": 1500}},
+	            {"name": "note", "kind": "switch",
+	             "default": {"kind": "const", "value": ""},
+	             "cases": [{"when": {"field": "status", "op": "eq",
+	                                 "value": "closed"},
+	                        "value": {"kind": "const", "value": "done"}}]}
+	        ]
+	    }
+	}
+
+The generator kinds are:
+
+  - const: always gives the same string value
+  - incrementing: an int or float starting at the initial value and
+    increasing by incr each time
+  - normal: an int or float, normally distributed with the given mean and
+    sd and constrained to lie between min and max
+  - weightedStrings: strings chosen from the values in proportion to
+    their weights. The order may be random (the default), sequential,
+    shuffled (each value is used as many times as its weight in each
+    cycle) or noReplacement (as for shuffled but generation stops with an
+    error once every value has been used)
+  - time: times starting at start (an RFC 3339 time) and advancing by a
+    constant interval, by a normally distributed (gaussian) interval or
+    to the next time of a cron schedule (such as "30 9-17 * * MON-FRI"),
+    optionally delayed by a random jitter of up to the given duration.
+    If an end time is given the times stop before it; atEnd gives what
+    happens then: stop (the default) ends the generation with an error
+    and wrap starts again from the start time
+  - money: an amount in the currency of the country given (by its ISO
+    3166 code) where the amount, in the minor currency unit, is generated
+    by an incrementing or normal generator
+  - switch: the value of the first case whose condition on a previously
+    defined field is true or the default value if none of them is
+
+If a seed is given, either in the specification or as a parameter, then
+the same data will be generated each time, provided any time fields have a
+start time.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+const (
+	dfltRows = 10
+)
+
+// prog records the program parameters
+type prog struct {
+	specFile string
+	outFile  string
+	format   string
+	table    string
+	rows     int
+	noTitles bool
+	seed     uint64
+	seedSet  bool
+}
+
+// newRecordWriter returns the writer for the chosen output format
+func (p prog) newRecordWriter() (datagen.RecordWriter, error) {
+	switch p.format {
+	case "csv":
+		return datagen.NewCSVWriter(
+			datagen.CSVWriterSetShowTitles(!p.noTitles)), nil
+	case "tsv":
+		return datagen.NewTSVWriter(
+			datagen.CSVWriterSetShowTitles(!p.noTitles)), nil
+	case "jsonl":
+		return datagen.NewJSONWriter(), nil
+	case "json":
+		return datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)), nil
+	case "sql", "copy":
+		opts := []datagen.SQLWriterOptFunc{}
+		if p.format == "copy" {
+			opts = append(opts, datagen.SQLWriterSetStyle(datagen.SQLCopy))
+		}
+
+		if p.table != "" {
+			opts = append(opts, datagen.SQLWriterSetTable(p.table))
+		}
+
+		return datagen.NewSQLWriter(opts...), nil
+	}
+
+	return nil, fmt.Errorf("unknown format: %q"+
+		" (use csv, tsv, json, jsonl, sql or copy)", p.format)
+}
+
+// run reads the spec, builds the record and writes the generated rows
+func (p prog) run() error {
+	if p.specFile == "" {
+		return errors.New("no spec file has been given")
+	}
+
+	if p.rows < 0 {
+		return fmt.Errorf("the number of rows (%d) must be >= 0", p.rows)
+	}
+
+	f, err := os.Open(p.specFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	spec, err := readSpec(f)
+	if err != nil {
+		return err
+	}
+
+	var seeder *datagen.Seeder
+
+	switch {
+	case p.seedSet:
+		seeder = datagen.NewSeeder(p.seed)
+	case spec.Seed != nil:
+		seeder = datagen.NewSeeder(*spec.Seed)
+	}
+
+	rec, err := buildRecord(spec.Record, seeder)
+	if err != nil {
+		return err
+	}
+
+	rw, err := p.newRecordWriter()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+
+	if p.outFile != "" {
+		out, err = os.Create(p.outFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rw.Write(out, rec, p.rows)
+
+	if p.outFile != "" {
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+	}
+
+	return err
+}
+
+func main() {
+	p := prog{}
+
+	flag.StringVar(&p.specFile, "spec", "",
+		"the name of the file holding the JSON data specification")
+	flag.StringVar(&p.outFile, "o", "",
+		"the name of the file to write to (default: standard output)")
+	flag.StringVar(&p.format, "format", "csv",
+		"the output format: csv, tsv, json, jsonl, sql or copy")
+	flag.StringVar(&p.table, "table", "",
+		"the table name for sql or copy output (default: the record name)")
+	flag.IntVar(&p.rows, "n", dfltRows, "the number of rows to generate")
+	flag.BoolVar(&p.noTitles, "no-titles", false,
+		"don't write the title row for csv or tsv output")
+	flag.Uint64Var(&p.seed, "seed", 0,
+		"the seed for the random values, overriding any seed in the spec")
+	flag.Parse()
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			p.seedSet = true
+		}
+	})
+
+	if err := p.run(); err != nil {
+		fmt.Fprintln(os.Stderr, "datagen:", err)
+		os.Exit(1)
+	}
+}
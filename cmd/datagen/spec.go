@@ -0,0 +1,664 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// Spec describes the data to be generated
+type Spec struct {
+	// Seed, if given, is used to seed the random number generators so that
+	// the same data is generated each time
+	Seed *uint64 `json:"seed"`
+
+	Record RecordSpec `json:"record"`
+}
+
+// RecordSpec describes a record and its fields
+type RecordSpec struct {
+	Name   string      `json:"name"`
+	Fields []FieldSpec `json:"fields"`
+}
+
+// FieldSpec describes a named field and the generator of its values
+type FieldSpec struct {
+	Name string `json:"name"`
+	GenSpec
+}
+
+// GenSpec describes a generator. Which of the parameters are used depends
+// on the Kind.
+type GenSpec struct {
+	Kind string `json:"kind"`
+
+	// const
+	Value string `json:"value"`
+
+	// incrementing and normal
+	Type    string  `json:"type"`
+	Initial float64 `json:"initial"`
+	Incr    float64 `json:"incr"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Mean    float64 `json:"mean"`
+	SD      float64 `json:"sd"`
+
+	// weightedStrings
+	Order  string                   `json:"order"`
+	Values []datagen.WeightedString `json:"values"`
+
+	// time
+	Layout   string        `json:"layout"`
+	Start    string        `json:"start"`
+	Interval string        `json:"interval"`
+	Gaussian *GaussianSpec `json:"gaussian"`
+	Cron     string        `json:"cron"`
+	Jitter   string        `json:"jitter"`
+	End      string        `json:"end"`
+	AtEnd    string        `json:"atEnd"`
+
+	// money
+	Country string   `json:"country"`
+	Amount  *GenSpec `json:"amount"`
+
+	// switch
+	Default *GenSpec   `json:"default"`
+	Cases   []CaseSpec `json:"cases"`
+}
+
+// GaussianSpec describes a normally distributed time interval
+type GaussianSpec struct {
+	Mean     float64 `json:"mean"`
+	SD       float64 `json:"sd"`
+	Units    string  `json:"units"`
+	ForceGT0 bool    `json:"forceGT0"`
+}
+
+// CaseSpec describes a case in a switch
+type CaseSpec struct {
+	When  CondSpec `json:"when"`
+	Value GenSpec  `json:"value"`
+}
+
+// CondSpec describes a condition on the value of a previously defined field
+type CondSpec struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value any    `json:"value"`
+}
+
+// The generator kinds
+const (
+	kindConst       = "const"
+	kindIncr        = "incrementing"
+	kindNormal      = "normal"
+	kindWStrings    = "weightedStrings"
+	kindTime        = "time"
+	kindMoney       = "money"
+	kindSwitch      = "switch"
+	orderRandom     = "random"
+	orderSequential = "sequential"
+	orderShuffled   = "shuffled"
+	orderNoReplace  = "noReplacement"
+	atEndStop       = "stop"
+	atEndWrap       = "wrap"
+)
+
+// The value types of the generated fields
+const (
+	typeString = "string"
+	typeInt    = "int"
+	typeFloat  = "float"
+	typeTime   = "time"
+	typeMoney  = "money"
+)
+
+// readSpec reads the spec from the reader. Unknown JSON members are
+// reported as errors.
+func readSpec(r io.Reader) (*Spec, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	s := &Spec{}
+	if err := dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("bad spec: %w", err)
+	}
+
+	return s, nil
+}
+
+// built records a generator and the type of the value it generates
+type built struct {
+	g     datagen.Generator
+	vType string
+}
+
+// builder records the information needed while building the record
+type builder struct {
+	seeder *datagen.Seeder
+	fields map[string]built
+}
+
+// buildRecord constructs the record described by the spec
+func buildRecord(rs RecordSpec,
+	seeder *datagen.Seeder,
+) (*datagen.Record, error) {
+	if len(rs.Fields) == 0 {
+		return nil, errors.New("the record has no fields")
+	}
+
+	b := builder{
+		seeder: seeder,
+		fields: map[string]built{},
+	}
+
+	r := datagen.NewRecord(rs.Name)
+
+	for i, fs := range rs.Fields {
+		if fs.Name == "" {
+			return nil, fmt.Errorf("field %d has no name", i+1)
+		}
+
+		if _, exists := b.fields[fs.Name]; exists {
+			return nil, fmt.Errorf("field %q is given more than once", fs.Name)
+		}
+
+		bg, err := b.build(fs.GenSpec)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", fs.Name, err)
+		}
+
+		b.fields[fs.Name] = bg
+		r.AddFields(datagen.NewField(fs.Name, bg.g))
+	}
+
+	return r, nil
+}
+
+// build constructs the generator described by the GenSpec
+func (b builder) build(gs GenSpec) (built, error) {
+	switch gs.Kind {
+	case kindConst:
+		return built{
+			g:     datagen.NewGen(datagen.GenSetValue(gs.Value)),
+			vType: typeString,
+		}, nil
+	case kindIncr, kindNormal:
+		return b.buildNumeric(gs)
+	case kindWStrings:
+		return b.buildWStrings(gs)
+	case kindTime:
+		return b.buildTime(gs)
+	case kindMoney:
+		return b.buildMoney(gs)
+	case kindSwitch:
+		return b.buildSwitch(gs)
+	case "":
+		return built{}, errors.New("no kind is given")
+	}
+
+	return built{}, fmt.Errorf("unknown kind: %q", gs.Kind)
+}
+
+// numSetter returns the initial value and the value setter for a numeric
+// generator
+func numSetter[T int64 | float64](
+	b builder, gs GenSpec,
+) (T, datagen.ValSetter[T], error) {
+	if gs.Kind == kindIncr {
+		return T(gs.Initial), datagen.NewIncrementingValSetter(T(gs.Incr)), nil
+	}
+
+	if gs.Kind != kindNormal {
+		return 0, nil, fmt.Errorf("kind %q is not %q or %q",
+			gs.Kind, kindIncr, kindNormal)
+	}
+
+	if gs.Min > gs.Max {
+		return 0, nil, fmt.Errorf("min (%g) > max (%g)", gs.Min, gs.Max)
+	}
+
+	var opts []datagen.NormValSetterOptFunc[T]
+	if b.seeder != nil {
+		opts = append(opts, datagen.NormValSetterSetSeeder[T](b.seeder))
+	}
+
+	vs := datagen.NewNormValSetter(T(gs.Min), T(gs.Max), gs.Mean, gs.SD, opts...)
+
+	var v T
+	vs.SetVal(&v)
+
+	return v, vs, nil
+}
+
+// buildNumeric constructs an incrementing or normally distributed numeric
+// generator
+func (b builder) buildNumeric(gs GenSpec) (built, error) {
+	switch gs.Type {
+	case typeInt, "":
+		v, vs, err := numSetter[int64](b, gs)
+		if err != nil {
+			return built{}, err
+		}
+
+		return built{
+			g: datagen.NewGen(
+				datagen.GenSetValue(v), datagen.GenSetValSetter(vs)),
+			vType: typeInt,
+		}, nil
+	case typeFloat:
+		v, vs, err := numSetter[float64](b, gs)
+		if err != nil {
+			return built{}, err
+		}
+
+		return built{
+			g: datagen.NewGen(
+				datagen.GenSetValue(v), datagen.GenSetValSetter(vs)),
+			vType: typeFloat,
+		}, nil
+	}
+
+	return built{}, fmt.Errorf("unknown numeric type: %q (use %q or %q)",
+		gs.Type, typeInt, typeFloat)
+}
+
+// buildWStrings constructs a weighted string generator
+func (b builder) buildWStrings(gs GenSpec) (built, error) {
+	if len(gs.Values) == 0 {
+		return built{}, errors.New("no values are given")
+	}
+
+	for _, ws := range gs.Values {
+		if ws.Weight <= 0 {
+			return built{}, fmt.Errorf("the weight (%d) for %q is <= 0",
+				ws.Weight, ws.Str)
+		}
+	}
+
+	seqOrRand := datagen.Random
+
+	switch gs.Order {
+	case orderRandom, "":
+	case orderSequential:
+		seqOrRand = datagen.Sequential
+	case orderShuffled:
+		seqOrRand = datagen.Shuffled
+	case orderNoReplace:
+		seqOrRand = datagen.NoReplacement
+	default:
+		return built{}, fmt.Errorf("unknown order: %q (use %q, %q, %q or %q)",
+			gs.Order, orderRandom, orderSequential,
+			orderShuffled, orderNoReplace)
+	}
+
+	var opts []datagen.WStringGenOptFunc
+	if b.seeder != nil {
+		opts = append(opts, datagen.WStringGenSetSeeder(b.seeder))
+	}
+
+	return built{
+		g:     datagen.NewWStringGenWithOpts(seqOrRand, gs.Values, opts...),
+		vType: typeString,
+	}, nil
+}
+
+// buildTime constructs a time generator
+func (b builder) buildTime(gs GenSpec) (built, error) {
+	var opts []datagen.TimeGenOptFunc
+
+	if gs.Layout != "" {
+		opts = append(opts, datagen.TimeGenSetLayout(gs.Layout))
+	}
+
+	start := time.Now()
+
+	if gs.Start != "" {
+		var err error
+
+		start, err = time.Parse(time.RFC3339, gs.Start)
+		if err != nil {
+			return built{}, fmt.Errorf("bad start time: %w", err)
+		}
+	}
+
+	opts = append(opts, datagen.TimeGenSetInitialTime(start))
+
+	intervals := 0
+
+	for _, given := range []bool{
+		gs.Interval != "", gs.Gaussian != nil, gs.Cron != "",
+	} {
+		if given {
+			intervals++
+		}
+	}
+
+	if intervals > 1 {
+		return built{}, errors.New(
+			"only one of interval, gaussian and cron may be given")
+	}
+
+	if gs.Jitter != "" && gs.Cron == "" {
+		return built{}, errors.New("jitter may only be given with cron")
+	}
+
+	switch {
+	case gs.Interval != "":
+		d, err := time.ParseDuration(gs.Interval)
+		if err != nil {
+			return built{}, fmt.Errorf("bad interval: %w", err)
+		}
+
+		opts = append(opts,
+			datagen.TimeGenSetIntervalF(datagen.TimeGenConstIntervalF(d)))
+	case gs.Gaussian != nil:
+		f, err := b.gaussianIntervalF(*gs.Gaussian)
+		if err != nil {
+			return built{}, err
+		}
+
+		opts = append(opts, datagen.TimeGenSetIntervalF(f))
+	case gs.Cron != "":
+		f, err := b.cronIntervalF(gs.Cron, gs.Jitter)
+		if err != nil {
+			return built{}, err
+		}
+
+		opts = append(opts, datagen.TimeGenSetIntervalF(f))
+	}
+
+	if gs.End != "" || gs.AtEnd != "" {
+		endOpt, err := timeEnd(start, gs.End, gs.AtEnd)
+		if err != nil {
+			return built{}, err
+		}
+
+		opts = append(opts, endOpt)
+	}
+
+	return built{g: datagen.NewTimeGen(opts...), vType: typeTime}, nil
+}
+
+// gaussianIntervalF returns an interval func which gives normally
+// distributed intervals
+func (b builder) gaussianIntervalF(
+	gs GaussianSpec,
+) (datagen.TimeGenIntervalF, error) {
+	units := time.Second
+
+	if gs.Units != "" {
+		var err error
+
+		units, err = time.ParseDuration(gs.Units)
+		if err != nil {
+			return nil, fmt.Errorf("bad gaussian units: %w", err)
+		}
+	}
+
+	var opts []datagen.TimeValSetGaussianIntervalOptFunc
+	if b.seeder != nil {
+		opts = append(opts,
+			datagen.TimeValSetGaussianIntervalSetSeeder(b.seeder))
+	}
+
+	tvs := datagen.NewTimeValSetGaussianInterval(
+		gs.Mean, gs.SD, units, gs.ForceGT0, opts...)
+
+	return func(t time.Time) time.Duration {
+		next := t
+		tvs.SetVal(&next)
+
+		return next.Sub(t)
+	}, nil
+}
+
+// timeEnd returns the TimeGen option setting the end time and the action
+// taken when it is reached. The end time must be after the start.
+func timeEnd(start time.Time, end, atEnd string,
+) (datagen.TimeGenOptFunc, error) {
+	if end == "" {
+		return nil, errors.New("atEnd may only be given with end")
+	}
+
+	t, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return nil, fmt.Errorf("bad end time: %w", err)
+	}
+
+	if !t.After(start) {
+		return nil, fmt.Errorf("the end time (%s) must be after the start (%s)",
+			end, start.Format(time.RFC3339))
+	}
+
+	action := datagen.TimeGenEndStop
+
+	switch atEnd {
+	case "", atEndStop:
+	case atEndWrap:
+		action = datagen.TimeGenEndWrap
+	default:
+		return nil, fmt.Errorf("bad atEnd: %q (it must be %q or %q)",
+			atEnd, atEndStop, atEndWrap)
+	}
+
+	return datagen.TimeGenSetEnd(t, action), nil
+}
+
+// cronIntervalF returns an interval func which gives the times of the cron
+// schedule, delayed by a random jitter if one is given
+func (b builder) cronIntervalF(
+	expr, jitterText string,
+) (datagen.TimeGenIntervalF, error) {
+	cs, err := datagen.ParseCronSchedule(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var jitter time.Duration
+
+	if jitterText != "" {
+		jitter, err = time.ParseDuration(jitterText)
+		if err != nil {
+			return nil, fmt.Errorf("bad jitter: %w", err)
+		}
+
+		if jitter < 0 {
+			return nil, fmt.Errorf("the jitter (%s) must be >= 0", jitter)
+		}
+	}
+
+	var opts []datagen.TimeIntervalOptFunc
+	if b.seeder != nil {
+		opts = append(opts, datagen.TimeIntervalSetSeeder(b.seeder))
+	}
+
+	return datagen.TimeGenCronIntervalF(cs, jitter, opts...), nil
+}
+
+// buildMoney constructs a money generator
+func (b builder) buildMoney(gs GenSpec) (built, error) {
+	country, ok := datagen.Countries[gs.Country]
+	if !ok {
+		return built{}, fmt.Errorf("unknown country: %q", gs.Country)
+	}
+
+	if gs.Amount == nil {
+		return built{}, errors.New("no amount is given")
+	}
+
+	amt, vs, err := numSetter[int64](b, *gs.Amount)
+	if err != nil {
+		return built{}, fmt.Errorf("bad amount: %w", err)
+	}
+
+	ccy := country.Ccy()
+	nf := ccy.NumFmtWithCCY(country.NF())
+	f := ccy.MoneyMkStrFunc(&nf)
+
+	return built{
+		g: datagen.NewGen(
+			datagen.GenSetValue(datagen.Money{Amt: amt, Ccy: ccy}),
+			datagen.GenSetValSetter[datagen.Money](
+				datagen.NewMoneyValSetter(vs)),
+			datagen.GenSetStringMaker[datagen.Money](
+				datagen.NewMoneyStringMaker(func(m datagen.Money) string {
+					return f(m.Amt)
+				})),
+		),
+		vType: typeMoney,
+	}, nil
+}
+
+// buildSwitch constructs a switch generator. The type of the switch is
+// given by the default value and all the cases must have the same type.
+func (b builder) buildSwitch(gs GenSpec) (built, error) {
+	if gs.Default == nil {
+		return built{}, errors.New("no default is given")
+	}
+
+	dflt, err := b.build(*gs.Default)
+	if err != nil {
+		return built{}, fmt.Errorf("bad default: %w", err)
+	}
+
+	switch dflt.vType {
+	case typeString:
+		return buildTypedSwitch[string](b, dflt, gs.Cases)
+	case typeInt:
+		return buildTypedSwitch[int64](b, dflt, gs.Cases)
+	case typeFloat:
+		return buildTypedSwitch[float64](b, dflt, gs.Cases)
+	case typeTime:
+		return buildTypedSwitch[time.Time](b, dflt, gs.Cases)
+	case typeMoney:
+		return buildTypedSwitch[datagen.Money](b, dflt, gs.Cases)
+	}
+
+	return built{}, fmt.Errorf("a switch cannot have a value of type %q",
+		dflt.vType)
+}
+
+// buildTypedSwitch constructs a switch generator of type T
+func buildTypedSwitch[T any](
+	b builder, dflt built, caseSpecs []CaseSpec,
+) (built, error) {
+	dfltG, ok := dflt.g.(datagen.TypedGenerator[T])
+	if !ok {
+		return built{}, fmt.Errorf("the default (%s) has no typed value",
+			dflt.vType)
+	}
+
+	cases := make([]*datagen.Case[T], 0, len(caseSpecs))
+
+	for i, cs := range caseSpecs {
+		v, err := b.build(cs.Value)
+		if err != nil {
+			return built{}, fmt.Errorf("case %d: bad value: %w", i+1, err)
+		}
+
+		vG, ok := v.g.(datagen.TypedGenerator[T])
+		if !ok || v.vType != dflt.vType {
+			return built{}, fmt.Errorf(
+				"case %d: the value type (%s) differs from the default (%s)",
+				i+1, v.vType, dflt.vType)
+		}
+
+		vCk, err := b.buildCond(cs.When)
+		if err != nil {
+			return built{}, fmt.Errorf("case %d: %w", i+1, err)
+		}
+
+		cases = append(cases, datagen.NewCase(vCk, vG))
+	}
+
+	return built{
+		g:     datagen.NewSwitchGen(dfltG, cases...),
+		vType: dflt.vType,
+	}, nil
+}
+
+// buildCond constructs a value check from the condition. The field it
+// refers to must already have been defined.
+func (b builder) buildCond(c CondSpec) (*datagen.ValCk, error) {
+	f, ok := b.fields[c.Field]
+	if !ok {
+		return nil, fmt.Errorf("the condition refers to field %q"+
+			" which has not been defined before this field", c.Field)
+	}
+
+	switch f.vType {
+	case typeString:
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("the value for field %q must be a string",
+				c.Field)
+		}
+
+		return condValCk[string](f, c.Op, s)
+	case typeInt:
+		n, ok := c.Value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("the value for field %q must be a number",
+				c.Field)
+		}
+
+		return condValCk(f, c.Op, int64(n))
+	case typeFloat:
+		n, ok := c.Value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("the value for field %q must be a number",
+				c.Field)
+		}
+
+		return condValCk(f, c.Op, n)
+	}
+
+	return nil, fmt.Errorf("conditions cannot refer to field %q of type %s",
+		c.Field, f.vType)
+}
+
+// condValCk constructs a value check comparing the value of the field with
+// the supplied value using the named operator.
+func condValCk[T string | int64 | float64](
+	f built, op string, val T,
+) (*datagen.ValCk, error) {
+	tv, ok := f.g.(datagen.TypedVal[T])
+	if !ok {
+		return nil, fmt.Errorf("the field has no typed value of type %s",
+			f.vType)
+	}
+
+	var cmp func(v T) bool
+
+	switch op {
+	case "eq", "":
+		cmp = func(v T) bool { return v == val }
+	case "ne":
+		cmp = func(v T) bool { return v != val }
+	case "lt":
+		cmp = func(v T) bool { return v < val }
+	case "le":
+		cmp = func(v T) bool { return v <= val }
+	case "gt":
+		cmp = func(v T) bool { return v > val }
+	case "ge":
+		cmp = func(v T) bool { return v >= val }
+	default:
+		return nil, fmt.Errorf(
+			"unknown operator: %q (use eq, ne, lt, le, gt or ge)", op)
+	}
+
+	return datagen.NewValCk(func(v T) error {
+		if cmp(v) {
+			return nil
+		}
+
+		return fmt.Errorf("%v %s %v is false", v, op, val)
+	}, tv), nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+const testSpec = `{
+    "seed": 42,
+    "record": {
+        "name": "orders",
+        "fields": [
+            {"name": "id", "kind": "incrementing", "initial": 1, "incr": 1},
+            {"name": "when", "kind": "time",
+             "start": "2024-01-01T09:00:00Z", "interval": "90s",
+             "layout": "15:04:05"},
+            {"name": "status", "kind": "weightedStrings",
+             "values": [{"str": "open", "weight": 3},
+                        {"str": "closed", "weight": 1}]},
+            {"name": "qty", "kind": "normal", "type": "int",
+             "min": 1, "max": 100, "mean": 10, "sd": 5},
+            {"name": "note", "kind": "switch",
+             "default": {"kind": "const", "value": "-"},
+             "cases": [{"when": {"field": "status", "op": "eq",
+                                 "value": "closed"},
+                        "value": {"kind": "const", "value": "done"}}]}
+        ]
+    }
+}`
+
+// genCSV builds the record from the spec and returns the rows it
+// generates as CSV
+func genCSV(t *testing.T, specText string, rows int) string {
+	t.Helper()
+
+	spec, err := readSpec(strings.NewReader(specText))
+	if err != nil {
+		t.Fatalf("unexpected error reading the spec: %v", err)
+	}
+
+	rec, err := buildRecord(spec.Record, datagen.NewSeeder(*spec.Seed))
+	if err != nil {
+		t.Fatalf("unexpected error building the record: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := datagen.NewCSVWriter().Write(&buf, rec, rows); err != nil {
+		t.Fatalf("unexpected error writing the rows: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestBuildRecord(t *testing.T) {
+	const rows = 20
+
+	out := genCSV(t, testSpec, rows)
+
+	if again := genCSV(t, testSpec, rows); again != out {
+		t.Errorf("the same seed gave different output:\n%s\nand:\n%s",
+			out, again)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != rows+1 {
+		t.Fatalf("expected %d lines, got %d:\n%s", rows+1, len(lines), out)
+	}
+
+	if lines[0] != "id,when,status,qty,note" {
+		t.Errorf("bad title row: %q", lines[0])
+	}
+
+	if !strings.HasPrefix(lines[2], "2,09:01:30,") {
+		t.Errorf("bad second row: %q", lines[2])
+	}
+
+	for _, l := range lines[1:] {
+		status := strings.Split(l, ",")[2]
+		note := strings.Split(l, ",")[4]
+
+		if (status == "closed") != (note == "done") {
+			t.Errorf("the note doesn't match the status: %q", l)
+		}
+	}
+}
+
+func TestBuildRecordErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		fields string
+		expErr string
+	}{
+		{
+			name:   "no kind",
+			fields: `{"name": "a"}`,
+			expErr: `field "a": no kind is given`,
+		},
+		{
+			name:   "unknown kind",
+			fields: `{"name": "a", "kind": "nonesuch"}`,
+			expErr: `field "a": unknown kind: "nonesuch"`,
+		},
+		{
+			name: "duplicate field",
+			fields: `{"name": "a", "kind": "const"},
+			         {"name": "a", "kind": "const"}`,
+			expErr: `field "a" is given more than once`,
+		},
+		{
+			name:   "min > max",
+			fields: `{"name": "a", "kind": "normal", "min": 2, "max": 1}`,
+			expErr: `field "a": min (2) > max (1)`,
+		},
+		{
+			name: "bad order",
+			fields: `{"name": "a", "kind": "weightedStrings", "order": "up",
+			          "values": [{"str": "x", "weight": 1}]}`,
+			expErr: `field "a": unknown order: "up"`,
+		},
+		{
+			name: "interval and gaussian",
+			fields: `{"name": "a", "kind": "time", "interval": "1s",
+			          "gaussian": {"mean": 1, "sd": 1}}`,
+			expErr: `field "a": only one of interval, gaussian and cron`,
+		},
+	}
+
+	for _, tc := range testCases {
+		spec, err := readSpec(strings.NewReader(
+			`{"record": {"name": "r", "fields": [` + tc.fields + `]}}`))
+		if err != nil {
+			t.Errorf("%s: unexpected error reading the spec: %v", tc.name, err)
+			continue
+		}
+
+		_, err = buildRecord(spec.Record, nil)
+		if err == nil {
+			t.Errorf("%s: an error was expected", tc.name)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), tc.expErr) {
+			t.Errorf("%s: the error should contain %q, got: %v",
+				tc.name, tc.expErr, err)
+		}
+	}
+}
+
+func TestReadSpecUnknownMember(t *testing.T) {
+	_, err := readSpec(strings.NewReader(`{"record": {"nmae": "r"}}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "nmae"`) {
+		t.Errorf("an unknown field error was expected, got: %v", err)
+	}
+}
@@ -0,0 +1,459 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxCalendarSearchDays is the number of days searched for the next
+// business day before giving up. It is only reached if the holidays cover
+// every business day for this long.
+const maxCalendarSearchDays = 3660
+
+// dfltBusinessDayLayout is the default layout of the dates generated by a
+// BusinessDayGen
+const dfltBusinessDayLayout = time.DateOnly
+
+// Session records the opening and closing times of a trading session as
+// offsets from midnight. The close is not included in the session.
+type Session struct {
+	Open, Close time.Duration
+}
+
+// calDate records a date without a time or location
+type calDate struct {
+	y int
+	m time.Month
+	d int
+}
+
+// annualDate records a date recurring each year
+type annualDate struct {
+	m time.Month
+	d int
+}
+
+// Calendar records the days and times when business is done: the weekend
+// days, the holidays and the trading sessions of each day. Dates and times
+// are interpreted in the Calendar's location. By default the weekend is
+// Saturday and Sunday, there are no holidays, the whole day is open and the
+// location is UTC.
+type Calendar struct {
+	loc      *time.Location
+	weekend  [daysPerWeek]bool
+	holidays map[calDate]bool
+	annual   map[annualDate]bool
+	sessions [daysPerWeek][]Session
+	hasHours bool
+}
+
+// CalendarOptFunc is the type of an option-setting function that will set
+// a value in a Calendar
+type CalendarOptFunc func(cal *Calendar) error
+
+// CalendarSetLocation returns a Calendar Opt function which sets the
+// location in which dates and times are interpreted
+func CalendarSetLocation(loc *time.Location) CalendarOptFunc {
+	return func(cal *Calendar) error {
+		if loc == nil {
+			return errors.New("a nil location has been supplied")
+		}
+
+		cal.loc = loc
+
+		return nil
+	}
+}
+
+// CalendarSetWeekend returns a Calendar Opt function which sets the
+// weekend days, replacing the default of Saturday and Sunday
+func CalendarSetWeekend(days ...time.Weekday) CalendarOptFunc {
+	return func(cal *Calendar) error {
+		var weekend [daysPerWeek]bool
+
+		for _, d := range days {
+			if d < time.Sunday || d > time.Saturday {
+				return fmt.Errorf("bad weekday: %d", d)
+			}
+
+			weekend[d] = true
+		}
+
+		cal.weekend = weekend
+
+		return nil
+	}
+}
+
+// CalendarAddHolidays returns a Calendar Opt function which adds holidays.
+// Only the date of each time is used, in the time's own location.
+func CalendarAddHolidays(dates ...time.Time) CalendarOptFunc {
+	return func(cal *Calendar) error {
+		for _, t := range dates {
+			y, m, d := t.Date()
+			cal.holidays[calDate{y: y, m: m, d: d}] = true
+		}
+
+		return nil
+	}
+}
+
+// CalendarAddAnnualHoliday returns a Calendar Opt function which adds a
+// holiday on the same date every year. No substitute day is given if the
+// date falls on a weekend.
+func CalendarAddAnnualHoliday(m time.Month, d int) CalendarOptFunc {
+	return func(cal *Calendar) error {
+		if m < time.January || m > time.December {
+			return fmt.Errorf("bad month: %d", m)
+		}
+
+		if d < 1 || d > 31 {
+			return fmt.Errorf("bad day of the month: %d", d)
+		}
+
+		cal.annual[annualDate{m: m, d: d}] = true
+
+		return nil
+	}
+}
+
+// CalendarSetSessions returns a Calendar Opt function which sets the
+// trading sessions for the given weekdays, or for every day if no weekdays
+// are given. More than one session can be given, for instance to allow for
+// a lunch break, but they must not overlap. Once sessions have been set for
+// any day, days without sessions are closed all day.
+func CalendarSetSessions(sessions []Session,
+	days ...time.Weekday,
+) CalendarOptFunc {
+	return func(cal *Calendar) error {
+		if len(sessions) == 0 {
+			return errors.New("no sessions have been given")
+		}
+
+		s := slices.Clone(sessions)
+		slices.SortFunc(s, func(a, b Session) int {
+			return int(a.Open - b.Open)
+		})
+
+		for i, sess := range s {
+			if sess.Open < 0 || sess.Close > 24*time.Hour ||
+				sess.Open >= sess.Close {
+				return fmt.Errorf("bad session: %s - %s"+
+					" (the open must be before the close, within the day)",
+					sess.Open, sess.Close)
+			}
+
+			if i > 0 && sess.Open < s[i-1].Close {
+				return fmt.Errorf("the sessions overlap: %s - %s and %s - %s",
+					s[i-1].Open, s[i-1].Close, sess.Open, sess.Close)
+			}
+		}
+
+		if len(days) == 0 {
+			days = []time.Weekday{
+				time.Sunday, time.Monday, time.Tuesday, time.Wednesday,
+				time.Thursday, time.Friday, time.Saturday,
+			}
+		}
+
+		for _, d := range days {
+			if d < time.Sunday || d > time.Saturday {
+				return fmt.Errorf("bad weekday: %d", d)
+			}
+
+			cal.sessions[d] = s
+		}
+
+		cal.hasHours = true
+
+		return nil
+	}
+}
+
+// NewCalendar creates a new Calendar. It will panic if every day is a
+// weekend day, if sessions have been set but none of them is on a day
+// outside the weekend (so the Calendar would never open) or if any of the
+// option functions returns an error.
+func NewCalendar(opts ...CalendarOptFunc) *Calendar {
+	cal := &Calendar{
+		loc:      time.UTC,
+		holidays: map[calDate]bool{},
+		annual:   map[annualDate]bool{},
+	}
+	cal.weekend[time.Saturday] = true
+	cal.weekend[time.Sunday] = true
+
+	for _, o := range opts {
+		if err := o(cal); err != nil {
+			panic(err)
+		}
+	}
+
+	if !slices.Contains(cal.weekend[:], false) {
+		panic(errors.New("every day of the week is a weekend day"))
+	}
+
+	if cal.hasHours && !cal.hasWeekdaySessions() {
+		panic(errors.New(
+			"there are no sessions on any day outside the weekend"))
+	}
+
+	return cal
+}
+
+// hasWeekdaySessions returns true if there are sessions on at least one
+// day which is not a weekend day
+func (cal Calendar) hasWeekdaySessions() bool {
+	for d, s := range cal.sessions {
+		if !cal.weekend[d] && len(s) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Location returns the location in which the Calendar's dates and times
+// are interpreted
+func (cal Calendar) Location() *time.Location {
+	return cal.loc
+}
+
+// IsBusinessDay returns true if the date of the time, in the Calendar's
+// location, is neither a weekend day nor a holiday
+func (cal Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(cal.loc)
+	if cal.weekend[t.Weekday()] {
+		return false
+	}
+
+	y, m, d := t.Date()
+
+	return !cal.holidays[calDate{y: y, m: m, d: d}] &&
+		!cal.annual[annualDate{m: m, d: d}]
+}
+
+// daySessions returns the sessions for the day of the time. If no sessions
+// have been set for any day the whole day is open.
+func (cal Calendar) daySessions(t time.Time) []Session {
+	if !cal.hasHours {
+		return []Session{{Open: 0, Close: 24 * time.Hour}}
+	}
+
+	return cal.sessions[t.Weekday()]
+}
+
+// atOffset returns the time on the date of the day at the given offset from
+// midnight, in the Calendar's location. The offset is applied to the wall
+// clock so that days with a daylight saving change are handled correctly.
+func (cal Calendar) atOffset(day time.Time, off time.Duration) time.Time {
+	y, m, d := day.Date()
+	h := int(off / time.Hour)
+	mins := int(off % time.Hour / time.Minute)
+	ns := int(off % time.Minute)
+
+	return time.Date(y, m, d, h, mins, 0, ns, cal.loc)
+}
+
+// IsOpen returns true if the time is on a business day and within one of
+// that day's sessions
+func (cal Calendar) IsOpen(t time.Time) bool {
+	return cal.NextOpen(t).Equal(t)
+}
+
+// NextOpen returns the time if the Calendar is open at that time and the
+// start of the next session otherwise. The result is in the Calendar's
+// location. It will panic if no business day is found within ten years.
+func (cal Calendar) NextOpen(t time.Time) time.Time {
+	t = t.In(cal.loc)
+	day := cal.atOffset(t, 0)
+
+	for range maxCalendarSearchDays {
+		if cal.IsBusinessDay(day) {
+			for _, s := range cal.daySessions(day) {
+				end := cal.atOffset(day, s.Close)
+				if !t.Before(end) {
+					continue
+				}
+
+				if start := cal.atOffset(day, s.Open); t.Before(start) {
+					return start
+				}
+
+				return t
+			}
+		}
+
+		y, m, d := day.Date()
+		day = time.Date(y, m, d+1, 0, 0, 0, 0, cal.loc)
+	}
+
+	panic(fmt.Errorf("no business day found within %d days of %s",
+		maxCalendarSearchDays, t))
+}
+
+// NextBusinessDay returns midnight, in the Calendar's location, at the
+// start of the first business day on or after the date of the time. It
+// will panic if no business day is found within ten years.
+func (cal Calendar) NextBusinessDay(t time.Time) time.Time {
+	t = t.In(cal.loc)
+	y, m, d := t.Date()
+
+	for i := range maxCalendarSearchDays {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, cal.loc)
+		if cal.IsBusinessDay(day) {
+			return day
+		}
+	}
+
+	panic(fmt.Errorf("no business day found within %d days of %s",
+		maxCalendarSearchDays, t))
+}
+
+// AddBusinessDays returns midnight, in the Calendar's location, at the
+// start of the n'th business day after the date of the time. The date of
+// the time need not itself be a business day. It will panic if n is < 0.
+func (cal Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	if n < 0 {
+		panic(fmt.Errorf("the number of business days (%d) must be >= 0", n))
+	}
+
+	t = t.In(cal.loc)
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, cal.loc)
+
+	for range n {
+		y, m, d = day.Date()
+		day = cal.NextBusinessDay(time.Date(y, m, d+1, 0, 0, 0, 0, cal.loc))
+	}
+
+	return day
+}
+
+// ===================================================================
+
+// CalendarValSetter wraps a ValSetter[time.Time] and rolls each time it
+// sets forward to the next time that the Calendar is open
+type CalendarValSetter struct {
+	vs  ValSetter[time.Time]
+	cal *Calendar
+}
+
+// NewCalendarValSetter creates and returns a CalendarValSetter. It will
+// panic if the ValSetter or the Calendar is nil.
+func NewCalendarValSetter(vs ValSetter[time.Time],
+	cal *Calendar,
+) *CalendarValSetter {
+	if vs == nil {
+		panic(errors.New("a nil value setter has been supplied"))
+	}
+
+	if cal == nil {
+		panic(errors.New("a nil Calendar has been supplied"))
+	}
+
+	return &CalendarValSetter{vs: vs, cal: cal}
+}
+
+// SetVal sets the time using the wrapped ValSetter and then rolls it
+// forward to the next open time
+func (cvs CalendarValSetter) SetVal(t *time.Time) {
+	cvs.vs.SetVal(t)
+	*t = cvs.cal.NextOpen(*t)
+}
+
+// ===================================================================
+
+// BusinessDayGen generates dates which are business days of a Calendar,
+// stepping forward a number of business days each time. It implements the
+// TypedGenerator interface; the value is midnight at the start of the
+// date, in the Calendar's location.
+type BusinessDayGen struct {
+	cal    *Calendar
+	layout string
+	value  time.Time
+	step   int
+}
+
+// BusinessDayGenOptFunc is the type of an option-setting function that
+// will set a value in a BusinessDayGen
+type BusinessDayGenOptFunc func(bdg *BusinessDayGen) error
+
+// BusinessDayGenSetLayout returns a BusinessDayGen Opt function which sets
+// the layout (the format for displaying the date). The default is
+// "2006-01-02".
+func BusinessDayGenSetLayout(layout string) BusinessDayGenOptFunc {
+	return func(bdg *BusinessDayGen) error {
+		bdg.layout = layout
+		return nil
+	}
+}
+
+// BusinessDayGenSetInitialDate returns a BusinessDayGen Opt function which
+// sets the initial date. If it is not a business day the next business day
+// is used. The default value is the current date.
+func BusinessDayGenSetInitialDate(t time.Time) BusinessDayGenOptFunc {
+	return func(bdg *BusinessDayGen) error {
+		bdg.value = t
+		return nil
+	}
+}
+
+// BusinessDayGenSetStep returns a BusinessDayGen Opt function which sets
+// the number of business days to step forward each time. The default is 1.
+func BusinessDayGenSetStep(n int) BusinessDayGenOptFunc {
+	return func(bdg *BusinessDayGen) error {
+		if n <= 0 {
+			return fmt.Errorf("the step (%d) must be > 0", n)
+		}
+
+		bdg.step = n
+
+		return nil
+	}
+}
+
+// NewBusinessDayGen creates a new BusinessDayGen generating business days
+// of the Calendar. It will panic if the Calendar is nil or if any of the
+// option functions returns an error.
+func NewBusinessDayGen(cal *Calendar,
+	opts ...BusinessDayGenOptFunc,
+) *BusinessDayGen {
+	if cal == nil {
+		panic(errors.New("a nil Calendar has been supplied"))
+	}
+
+	bdg := &BusinessDayGen{
+		cal:    cal,
+		layout: dfltBusinessDayLayout,
+		value:  time.Now(),
+		step:   1,
+	}
+
+	for _, o := range opts {
+		if err := o(bdg); err != nil {
+			panic(err)
+		}
+	}
+
+	bdg.value = cal.NextBusinessDay(bdg.value)
+
+	return bdg
+}
+
+// Generate returns the formatted date
+func (bdg BusinessDayGen) Generate() string {
+	return bdg.value.Format(bdg.layout)
+}
+
+// Value returns the date as a time
+func (bdg BusinessDayGen) Value() time.Time {
+	return bdg.value
+}
+
+// Next moves the date on by the step number of business days
+func (bdg *BusinessDayGen) Next() {
+	bdg.value = bdg.cal.AddBusinessDays(bdg.value, bdg.step)
+}
@@ -0,0 +1,137 @@
+package datagen_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// calTime returns the time on the given day of January 2024 (the 1st is a
+// Monday) in UTC
+func calTime(day, h, m int) time.Time {
+	return time.Date(2024, time.January, day, h, m, 0, 0, time.UTC)
+}
+
+func TestCalendarNextOpen(t *testing.T) {
+	cal := datagen.NewCalendar(
+		datagen.CalendarAddHolidays(calTime(3, 0, 0)),
+		datagen.CalendarSetSessions([]datagen.Session{
+			{Open: 13 * time.Hour, Close: 17 * time.Hour},
+			{Open: 9 * time.Hour, Close: 12 * time.Hour},
+		}))
+
+	testCases := []struct {
+		name string
+		t    time.Time
+		exp  time.Time
+	}{
+		{name: "open", t: calTime(1, 10, 0), exp: calTime(1, 10, 0)},
+		{name: "before the open", t: calTime(1, 7, 0), exp: calTime(1, 9, 0)},
+		{name: "lunch", t: calTime(1, 12, 0), exp: calTime(1, 13, 0)},
+		{name: "after the close", t: calTime(2, 17, 0), exp: calTime(4, 9, 0)},
+		{name: "holiday", t: calTime(3, 10, 0), exp: calTime(4, 9, 0)},
+		{name: "weekend", t: calTime(6, 10, 0), exp: calTime(8, 9, 0)},
+	}
+
+	for _, tc := range testCases {
+		if got := cal.NextOpen(tc.t); !got.Equal(tc.exp) {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.exp, got)
+		}
+
+		if cal.IsOpen(tc.t) != tc.t.Equal(tc.exp) {
+			t.Errorf("%s: bad IsOpen: %t", tc.name, cal.IsOpen(tc.t))
+		}
+	}
+}
+
+func TestBusinessDayGen(t *testing.T) {
+	cal := datagen.NewCalendar(
+		datagen.CalendarAddAnnualHoliday(time.January, 2))
+	bdg := datagen.NewBusinessDayGen(cal,
+		datagen.BusinessDayGenSetInitialDate(calTime(1, 12, 0)),
+		datagen.BusinessDayGenSetStep(2))
+
+	var got []string
+
+	for range 3 {
+		got = append(got, bdg.Generate())
+		bdg.Next()
+	}
+
+	exp := "2024-01-01 2024-01-04 2024-01-08"
+	if strings.Join(got, " ") != exp {
+		t.Errorf("bad dates\nexpected: %s\n     got: %s",
+			exp, strings.Join(got, " "))
+	}
+}
+
+func TestCountryCalendar(t *testing.T) {
+	cal, err := datagen.Countries["US"].Calendar()
+	if err != nil {
+		t.Skipf("the location is not available: %v", err)
+	}
+
+	// 9:00 in New York is before the open at 9:30
+	ny := cal.Location()
+	got := cal.NextOpen(time.Date(2024, time.January, 2, 9, 0, 0, 0, ny))
+
+	if exp := time.Date(2024, time.January, 2, 9, 30, 0, 0, ny); !got.Equal(exp) {
+		t.Errorf("expected %s, got %s", exp, got)
+	}
+}
+
+func TestNewCalendarBadArgs(t *testing.T) {
+	oneHour := []datagen.Session{{Open: 0, Close: time.Hour}}
+
+	testCases := []struct {
+		name   string
+		opts   []datagen.CalendarOptFunc
+		expErr string
+	}{
+		{
+			name: "every day is a weekend day",
+			opts: []datagen.CalendarOptFunc{
+				datagen.CalendarSetWeekend(time.Sunday, time.Monday,
+					time.Tuesday, time.Wednesday, time.Thursday,
+					time.Friday, time.Saturday),
+			},
+			expErr: "every day of the week is a weekend day",
+		},
+		{
+			name: "sessions only at the weekend",
+			opts: []datagen.CalendarOptFunc{
+				datagen.CalendarSetSessions(oneHour,
+					time.Saturday, time.Sunday),
+			},
+			expErr: "no sessions on any day outside the weekend",
+		},
+		{
+			name: "overlapping sessions",
+			opts: []datagen.CalendarOptFunc{
+				datagen.CalendarSetSessions([]datagen.Session{
+					{Open: 0, Close: 2 * time.Hour},
+					{Open: time.Hour, Close: 3 * time.Hour},
+				}),
+			},
+			expErr: "the sessions overlap",
+		},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				p := recover()
+				err, ok := p.(error)
+
+				if !ok || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("%s: a panic containing %q was expected, got: %v",
+						tc.name, tc.expErr, p)
+				}
+			}()
+
+			datagen.NewCalendar(tc.opts...)
+		}()
+	}
+}
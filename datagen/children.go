@@ -0,0 +1,250 @@
+package datagen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// mirrorGen reflects the current value of another Generator. It is used to
+// copy the key fields of a parent Record into its child Record. It does not
+// move the other Generator on; that is done by the parent Record.
+type mirrorGen struct {
+	g Generator
+}
+
+// Generate returns the value generated by the mirrored Generator
+func (mg mirrorGen) Generate() string {
+	return mg.g.Generate()
+}
+
+// Next does nothing; the mirrored Generator is moved on by its own Record
+func (mg mirrorGen) Next() {
+}
+
+// Value returns the typed value of the mirrored Generator if it has one and
+// the generated string otherwise
+func (mg mirrorGen) Value() any {
+	if v, ok := typedValue(mg.g); ok {
+		return v
+	}
+
+	return mg.g.Generate()
+}
+
+// IsNull returns true if the mirrored Generator's value is NULL
+func (mg mirrorGen) IsNull() bool {
+	return isNull(mg.g)
+}
+
+// isSameGen returns true if the Generators are the same pointer. Values
+// which are not pointers are never taken to be the same; comparing them
+// with == would panic if their type is not comparable.
+func isSameGen(a, b Generator) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+
+	return va.Kind() == reflect.Pointer && vb.IsValid() &&
+		va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
+
+// Children records a one-to-many relationship between a parent Record and a
+// child Record. For each row of the parent a number of child rows is
+// generated, the number being given by the count TypedGenerator. The
+// values of the parent's key fields are copied into each of its child rows.
+type Children struct {
+	name         string
+	parent       *Record
+	child        *Record
+	count        TypedGenerator[int]
+	countIsField bool
+}
+
+// NewChildren creates a new Children object relating the parent and child
+// Records. The name is used as the name of the array of children when the
+// children are nested in the parent's JSON. For each key a field with the
+// same name, taking its value from the parent field, is added at the start
+// of the child rows; the child Record passed is not changed, the Record
+// written, with the key fields added, is given by the Child method. The
+// count gives the number of children for each parent row; if it is the
+// Generator (the same pointer) of one of the parent's fields it is moved
+// on with the parent, otherwise it is moved on after each parent row. It
+// will panic if the Records or the count are nil, if a key is not a field
+// in the parent or if a key is already a field in the child.
+func NewChildren(name string, parent, child *Record,
+	count TypedGenerator[int], keys ...string,
+) *Children {
+	if parent == nil || child == nil {
+		panic(errors.New("a nil Record has been supplied"))
+	}
+
+	if count == nil {
+		panic(errors.New("a nil count TypedGenerator has been supplied"))
+	}
+
+	c := &Children{
+		name:   name,
+		parent: parent,
+		count:  count,
+	}
+
+	for _, f := range parent.fields {
+		if isSameGen(count, f.g) {
+			c.countIsField = true
+		}
+	}
+
+	keyFields := make([]*Field, 0, len(keys))
+
+	for _, k := range keys {
+		pf, ok := parent.Field(k)
+		if !ok {
+			panic(fmt.Errorf("key %q is not a field in the parent record %q",
+				k, parent.Name()))
+		}
+
+		if _, ok := child.Field(k); ok {
+			panic(fmt.Errorf("key %q is already a field in the child record %q",
+				k, child.Name()))
+		}
+
+		keyFields = append(keyFields, NewField(k, mirrorGen{g: pf.g}))
+	}
+
+	c.child = NewRecord(child.Name(), append(keyFields, child.fields...)...)
+
+	return c
+}
+
+// Child returns the child Record as written, with the key fields at the
+// start
+func (c *Children) Child() *Record {
+	return c.child
+}
+
+// nextParent moves the parent Record, and the count if it is not a parent
+// field, on to their next values. As for a Record, the parent is moved on
+// after each of its rows and the child after each of its rows.
+func (c *Children) nextParent() {
+	c.parent.Next()
+
+	if !c.countIsField {
+		c.count.Next()
+	}
+}
+
+// childCount returns the number of children for the current parent row
+func (c *Children) childCount() int {
+	return max(0, c.count.Value())
+}
+
+// WriteTables writes the given number of parent rows to the parent
+// io.Writer using the parent RecordWriter and their child rows to the child
+// io.Writer using the child RecordWriter. Any error encountered while
+// writing, or reported by either Record, is returned.
+func (c *Children) WriteTables(
+	pw io.Writer, prw RecordWriter,
+	cw io.Writer, crw RecordWriter,
+	rows int,
+) error {
+	pRW := prw.NewRowWriter(pw, c.parent)
+	cRW := crw.NewRowWriter(cw, c.child)
+
+	for range rows {
+		if err := pRW.WriteRow(); err != nil {
+			return err
+		}
+
+		for range c.childCount() {
+			if err := cRW.WriteRow(); err != nil {
+				return err
+			}
+
+			c.child.Next()
+		}
+
+		c.nextParent()
+	}
+
+	if err := pRW.Close(); err != nil {
+		return err
+	}
+
+	return cRW.Close()
+}
+
+// nestedJSON returns the current values of the parent Record as a JSON
+// object with a final member, named after the Children, holding an array
+// of the child rows
+func (c *Children) nestedJSON(jw *JSONWriter) ([]byte, error) {
+	parent, err := jw.RecordJSON(c.parent)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := marshalJSON(c.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.Write(parent[:len(parent)-1])
+
+	if len(c.parent.fields) > 0 {
+		buf.WriteByte(',')
+	}
+
+	buf.Write(name)
+	buf.WriteString(":[")
+
+	for i := range c.childCount() {
+		child, err := jw.RecordJSON(c.child)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.child.Err(); err != nil {
+			return nil, err
+		}
+
+		c.child.Next()
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.Write(child)
+	}
+
+	buf.WriteString("]}")
+
+	return buf.Bytes(), nil
+}
+
+// WriteNestedJSON writes the given number of parent rows to the io.Writer
+// as JSON objects, each with its child rows nested in an array named after
+// the Children. Any error encountered while writing, or reported by either
+// Record, is returned.
+func (c *Children) WriteNestedJSON(w io.Writer, jw *JSONWriter,
+	rows int,
+) error {
+	jrw := &jsonRowWriter{
+		w:       w,
+		r:       c.parent,
+		asArray: jw.asArray,
+		rowF:    func() ([]byte, error) { return c.nestedJSON(jw) },
+		last:    true,
+	}
+
+	for range rows {
+		if err := jrw.WriteRow(); err != nil {
+			return err
+		}
+
+		c.nextParent()
+	}
+
+	return jrw.Close()
+}
@@ -0,0 +1,139 @@
+package datagen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// cycleCount is a TypedGenerator giving the values in turn. It is not
+// comparable as it holds a slice.
+type cycleCount struct {
+	vals []int
+	pos  *int
+}
+
+func newCycleCount(vals ...int) cycleCount {
+	return cycleCount{vals: vals, pos: new(int)}
+}
+
+func (cc cycleCount) Value() int       { return cc.vals[*cc.pos%len(cc.vals)] }
+func (cc cycleCount) Generate() string { return strconv.Itoa(cc.Value()) }
+func (cc cycleCount) Next()            { *cc.pos++ }
+
+func TestChildrenWriteTables(t *testing.T) {
+	parent := datagen.NewRecord("orders", datagen.NewField("id", incrGen(1, 1)))
+	child := datagen.NewRecord("lines", datagen.NewField("line", incrGen(1, 1)))
+
+	c := datagen.NewChildren("lines", parent, child, newCycleCount(2, 0, 1),
+		"id")
+
+	if _, ok := child.Field("id"); ok {
+		t.Error("the key field was added to the child Record passed")
+	}
+
+	if _, ok := c.Child().Field("id"); !ok {
+		t.Error("the key field is missing from the child Record written")
+	}
+
+	var pBuf, cBuf bytes.Buffer
+
+	cw := datagen.NewCSVWriter()
+	if err := c.WriteTables(&pBuf, cw, &cBuf, cw, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exp := "id\n1\n2\n3\n"; pBuf.String() != exp {
+		t.Errorf("bad parent rows\nexpected: %q\n     got: %q",
+			exp, pBuf.String())
+	}
+
+	if exp := "id,line\n1,1\n1,2\n3,3\n"; cBuf.String() != exp {
+		t.Errorf("bad child rows\nexpected: %q\n     got: %q",
+			exp, cBuf.String())
+	}
+}
+
+// TestChildrenCountIsField checks that a count which is a parent field is
+// moved on with the parent and that an uncomparable count is allowed
+func TestChildrenCountIsField(t *testing.T) {
+	n := newCycleCount(1, 2)
+	parent := datagen.NewRecord("p",
+		datagen.NewField("other", newCycleCount(5)),
+		datagen.NewField("n", &n))
+	child := datagen.NewRecord("c", datagen.NewField("v", incrGen(0, 1)))
+
+	c := datagen.NewChildren("kids", parent, child, &n)
+
+	var buf bytes.Buffer
+	if err := c.WriteNestedJSON(&buf, datagen.NewJSONWriter(), 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := `{"other":5,"n":1,"kids":[{"v":0}]}` + "\n" +
+		`{"other":5,"n":2,"kids":[{"v":1},{"v":2}]}` + "\n" +
+		`{"other":5,"n":1,"kids":[{"v":3}]}` + "\n"
+	if buf.String() != exp {
+		t.Errorf("bad output\nexpected: %s\n     got: %s", exp, buf.String())
+	}
+}
+
+func TestChildrenNestedJSONIsValid(t *testing.T) {
+	parent := datagen.NewRecord("orders", datagen.NewField("id", incrGen(1, 1)))
+	child := datagen.NewRecord("lines", datagen.NewField("line", incrGen(1, 1)))
+	c := datagen.NewChildren("lines", parent, child, newCycleCount(0, 3), "id")
+
+	var buf bytes.Buffer
+
+	err := c.WriteNestedJSON(&buf,
+		datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)), 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var orders []struct {
+		ID    int `json:"id"`
+		Lines []struct {
+			ID   int `json:"id"`
+			Line int `json:"line"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &orders); err != nil {
+		t.Fatalf("the output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	lines := 0
+
+	for _, o := range orders {
+		for _, l := range o.Lines {
+			lines++
+
+			if l.ID != o.ID || l.Line != lines {
+				t.Errorf("bad line %d for order %d: %+v", lines, o.ID, l)
+			}
+		}
+	}
+
+	if len(orders) != 4 || lines != 6 {
+		t.Errorf("expected 4 orders and 6 lines, got %d and %d",
+			len(orders), lines)
+	}
+}
+
+// TestChildrenUncomparableCount checks that a count whose type is not
+// comparable can be given when a parent field has the same type
+func TestChildrenUncomparableCount(t *testing.T) {
+	defer func() {
+		if p := recover(); p != nil {
+			t.Errorf("unexpected panic: %v", p)
+		}
+	}()
+
+	parent := datagen.NewRecord("p", datagen.NewField("n", newCycleCount(1)))
+	child := datagen.NewRecord("c", datagen.NewField("v", incrGen(0, 1)))
+
+	datagen.NewChildren("kids", parent, child, newCycleCount(2))
+}
@@ -0,0 +1,165 @@
+package datagen
+
+import (
+	"fmt"
+	"time"
+)
+
+// countryCalendar records the defaults used to construct a Calendar for a
+// country. They are based on the country's main stock exchange.
+type countryCalendar struct {
+	tz       string
+	sessions []Session
+	annual   []annualDate
+}
+
+// hm returns the offset from midnight of the hour and minute
+func hm(h, m int) time.Duration {
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+}
+
+// countryCalendars gives the calendar defaults for the Countries. The
+// holidays are only those falling on the same date every year; holidays
+// whose dates move (such as Easter) must be added explicitly.
+//
+//nolint:mnd
+var countryCalendars = map[string]countryCalendar{
+	"US": {
+		tz:       "America/New_York",
+		sessions: []Session{{hm(9, 30), hm(16, 0)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.June, 19}, {time.July, 4},
+			{time.December, 25},
+		},
+	},
+	"CN": {
+		tz:       "Asia/Shanghai",
+		sessions: []Session{{hm(9, 30), hm(11, 30)}, {hm(13, 0), hm(15, 0)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.May, 1},
+			{time.October, 1}, {time.October, 2}, {time.October, 3},
+		},
+	},
+	"JP": {
+		tz:       "Asia/Tokyo",
+		sessions: []Session{{hm(9, 0), hm(11, 30)}, {hm(12, 30), hm(15, 30)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.January, 2}, {time.January, 3},
+			{time.December, 31},
+		},
+	},
+	"DE": {
+		tz:       "Europe/Berlin",
+		sessions: []Session{{hm(9, 0), hm(17, 30)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.May, 1}, {time.December, 24},
+			{time.December, 25}, {time.December, 26}, {time.December, 31},
+		},
+	},
+	"IN": {
+		tz:       "Asia/Kolkata",
+		sessions: []Session{{hm(9, 15), hm(15, 30)}},
+		annual: []annualDate{
+			{time.January, 26}, {time.August, 15}, {time.October, 2},
+		},
+	},
+	"GB": {
+		tz:       "Europe/London",
+		sessions: []Session{{hm(8, 0), hm(16, 30)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.December, 25}, {time.December, 26},
+		},
+	},
+	"FR": {
+		tz:       "Europe/Paris",
+		sessions: []Session{{hm(9, 0), hm(17, 30)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.May, 1},
+			{time.December, 25}, {time.December, 26},
+		},
+	},
+	"BR": {
+		tz:       "America/Sao_Paulo",
+		sessions: []Session{{hm(10, 0), hm(17, 0)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.April, 21}, {time.May, 1},
+			{time.September, 7}, {time.October, 12}, {time.November, 2},
+			{time.November, 15}, {time.December, 25},
+		},
+	},
+	"IT": {
+		tz:       "Europe/Rome",
+		sessions: []Session{{hm(9, 0), hm(17, 30)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.December, 24}, {time.December, 25},
+			{time.December, 26}, {time.December, 31},
+		},
+	},
+	"CA": {
+		tz:       "America/Toronto",
+		sessions: []Session{{hm(9, 30), hm(16, 0)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.July, 1},
+			{time.December, 25}, {time.December, 26},
+		},
+	},
+	"RU": {
+		tz:       "Europe/Moscow",
+		sessions: []Session{{hm(10, 0), hm(18, 50)}},
+		annual: []annualDate{
+			{time.January, 1}, {time.January, 2}, {time.January, 7},
+			{time.February, 23}, {time.March, 8}, {time.May, 1},
+			{time.May, 9}, {time.June, 12}, {time.November, 4},
+		},
+	},
+}
+
+// Location returns the location (time zone) of the country's main stock
+// exchange. An error is returned if there is no location for the country or
+// if it cannot be loaded (see time.LoadLocation).
+func (c Country) Location() (*time.Location, error) {
+	cc, ok := countryCalendars[c.code]
+	if !ok {
+		return nil, fmt.Errorf("there is no location for %s", c.name)
+	}
+
+	loc, err := time.LoadLocation(cc.tz)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the location for %s: %w",
+			c.name, err)
+	}
+
+	return loc, nil
+}
+
+// Calendar returns a new Calendar with the defaults for the country: the
+// location, trading sessions and fixed-date holidays of its main stock
+// exchange and a Saturday and Sunday weekend. The options are applied after
+// the defaults so they can be used to add holidays or override the
+// defaults. If there are no defaults for the country the Calendar has only
+// the standard defaults (see Calendar). An error is returned if the
+// country's location cannot be loaded (see time.LoadLocation); it will
+// panic if any of the option functions returns an error.
+func (c Country) Calendar(opts ...CalendarOptFunc) (*Calendar, error) {
+	cc, ok := countryCalendars[c.code]
+	if !ok {
+		return NewCalendar(opts...), nil
+	}
+
+	loc, err := c.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	dflts := []CalendarOptFunc{
+		CalendarSetLocation(loc),
+		CalendarSetSessions(cc.sessions,
+			time.Monday, time.Tuesday, time.Wednesday,
+			time.Thursday, time.Friday),
+	}
+	for _, ad := range cc.annual {
+		dflts = append(dflts, CalendarAddAnnualHoliday(ad.m, ad.d))
+	}
+
+	return NewCalendar(append(dflts, opts...)...), nil
+}
@@ -0,0 +1,325 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronLimitYears is the furthest ahead that the next time of a
+// CronSchedule is searched for. It must be long enough to find a schedule
+// firing only on the 29th of February across a century year.
+const cronLimitYears = 9
+
+// cronField describes one of the fields of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+// The fields of a cron expression, in order. The names, if any, give the
+// values starting from the minimum.
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{
+		name: "month", min: 1, max: 12,
+		names: []string{
+			"JAN", "FEB", "MAR", "APR", "MAY", "JUN",
+			"JUL", "AUG", "SEP", "OCT", "NOV", "DEC",
+		},
+	},
+	{
+		name: "day of week", min: 0, max: 7,
+		names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"},
+	},
+}
+
+// cronMacros gives the expressions that the cron macros stand for
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// CronSchedule records the times given by a cron expression. The times are
+// in the location of the time passed to Next.
+type CronSchedule struct {
+	expr    string
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// ParseCronSchedule parses the standard five-field cron expression (minute,
+// hour, day of month, month and day of week) and returns the schedule. Each
+// field may be '*', a value, a range (a-b) or a comma-separated list of
+// these; a '*' or range may be followed by a step (/n). Months and days of
+// the week may be given by their three-letter English names and Sunday may
+// be given as 0 or 7. As with the standard cron, if both the day of month
+// and the day of week are restricted (neither starts with '*') a day
+// matches if either of them matches. The macros @yearly (or @annually),
+// @monthly, @weekly, @daily (or @midnight) and @hourly are also allowed.
+//
+// An error is returned if the expression cannot be parsed or if the
+// schedule would never fire (such as on the 30th of February).
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	text := strings.TrimSpace(expr)
+	if m, ok := cronMacros[strings.ToLower(text)]; ok {
+		text = m
+	}
+
+	parts := strings.Fields(text)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("bad cron expression %q: %d fields found, %d expected",
+			expr, len(parts), len(cronFields))
+	}
+
+	cs := &CronSchedule{expr: expr}
+	sets := []*uint64{&cs.minute, &cs.hour, &cs.dom, &cs.month, &cs.dow}
+
+	for i, p := range parts {
+		bits, err := cronFields[i].parse(p)
+		if err != nil {
+			return nil, fmt.Errorf("bad cron expression %q: %w", expr, err)
+		}
+
+		*sets[i] = bits
+	}
+
+	const sundays = 1<<0 | 1<<7
+	if cs.dow&sundays != 0 {
+		cs.dow |= sundays
+	}
+
+	cs.domStar = strings.HasPrefix(parts[2], "*")
+	cs.dowStar = strings.HasPrefix(parts[4], "*")
+
+	from := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC) //nolint:mnd
+	if _, ok := cs.next(from); !ok {
+		return nil, fmt.Errorf("bad cron expression %q: it never fires", expr)
+	}
+
+	return cs, nil
+}
+
+// parse returns the set of values given by the text of the field
+func (cf cronField) parse(text string) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(text, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+
+		if hasStep {
+			var err error
+
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("bad %s step: %q", cf.name, stepText)
+			}
+		}
+
+		lo, hi := cf.min, cf.max
+
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+
+			var err error
+
+			if lo, err = cf.value(loText); err != nil {
+				return 0, err
+			}
+
+			switch {
+			case isRange:
+				if hi, err = cf.value(hiText); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				hi = lo
+			}
+
+			if lo > hi {
+				return 0, fmt.Errorf("bad %s range: %q", cf.name, rng)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// value returns the value given by the text, which may be a number or,
+// for fields having them, a name
+func (cf cronField) value(text string) (int, error) {
+	for i, n := range cf.names {
+		if strings.EqualFold(text, n) {
+			return cf.min + i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s: %q", cf.name, text)
+	}
+
+	if v < cf.min || v > cf.max {
+		return 0, fmt.Errorf("the %s (%d) must be between %d and %d",
+			cf.name, v, cf.min, cf.max)
+	}
+
+	return v, nil
+}
+
+// String returns the expression that the schedule was parsed from
+func (cs CronSchedule) String() string {
+	return cs.expr
+}
+
+// dayMatches returns true if the day of t is in the schedule
+func (cs CronSchedule) dayMatches(t time.Time) bool {
+	domOK := cs.dom&(1<<t.Day()) != 0
+	dowOK := cs.dow&(1<<t.Weekday()) != 0
+
+	if cs.domStar || cs.dowStar {
+		return domOK && dowOK
+	}
+
+	return domOK || dowOK
+}
+
+// Next returns the first time in the schedule after t, in the location of
+// t. Local times skipped when the clocks go forward are not in the
+// schedule and repeated local times are given each time they occur. The
+// zero time is returned if there is no such time in the following
+// cronLimitYears years; this can only happen for schedules firing only on
+// the 29th of February.
+func (cs CronSchedule) Next(t time.Time) time.Time {
+	next, _ := cs.next(t)
+	return next
+}
+
+// next returns the first time in the schedule after t and true or the zero
+// time and false if there is none within cronLimitYears years
+func (cs CronSchedule) next(t time.Time) (time.Time, bool) {
+	limit := t.AddDate(cronLimitYears, 0, 0)
+	loc := t.Location()
+
+	t = t.Add(time.Minute -
+		time.Duration(t.Second())*time.Second -
+		time.Duration(t.Nanosecond()))
+
+	for !t.After(limit) {
+		switch {
+		case cs.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !cs.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case cs.hour&(1<<t.Hour()) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute) //nolint:mnd
+		case cs.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// CronValSetter implements a ValSetter that will set the passed time to
+// the next time in a CronSchedule, optionally delayed by a random jitter,
+// as for the start times of scheduled jobs.
+type CronValSetter struct {
+	cs     *CronSchedule
+	jitter time.Duration
+	r      *rand.Rand
+
+	// sched and last record the last scheduled time and the time it was
+	// set to, after the jitter was added. The next time is found from the
+	// scheduled time so that the jitter doesn't cause times to be missed.
+	sched time.Time
+	last  time.Time
+}
+
+// NewCronValSetter creates and returns a CronValSetter. Each time is delayed
+// by a random amount, uniformly distributed between zero and the jitter;
+// if the jitter is zero the times are exactly as scheduled. The delay is
+// limited so that the time is always before the next scheduled time; the
+// times given are therefore always in order and the jitter is, in effect,
+// reduced where the scheduled times are closer together. It will panic
+// if the schedule is nil, if the jitter is < 0 or if any of the option
+// functions returns an error.
+func NewCronValSetter(cs *CronSchedule, jitter time.Duration,
+	opts ...TimeIntervalOptFunc,
+) *CronValSetter {
+	if cs == nil {
+		panic(errors.New("a nil CronSchedule has been supplied"))
+	}
+
+	if jitter < 0 {
+		panic(fmt.Errorf("the jitter (%s) must be >= 0", jitter))
+	}
+
+	return &CronValSetter{
+		cs:     cs,
+		jitter: jitter,
+		r:      newTimeIntervalOpts(opts...).r,
+	}
+}
+
+// SetVal sets the time to the next scheduled time, plus the jitter
+func (vs *CronValSetter) SetVal(t *time.Time) {
+	from := *t
+	if !vs.last.IsZero() && from.Equal(vs.last) {
+		from = vs.sched
+	}
+
+	vs.sched = vs.cs.Next(from)
+	vs.last = vs.sched
+
+	if vs.jitter > 0 && !vs.sched.IsZero() {
+		maxJitter := vs.jitter
+		if following, ok := vs.cs.next(vs.sched); ok {
+			maxJitter = min(maxJitter, following.Sub(vs.sched))
+		}
+
+		vs.last = vs.last.Add(time.Duration(vs.r.Int64N(int64(maxJitter))))
+	}
+
+	*t = vs.last
+}
+
+// TimeGenCronIntervalF returns an interval func which moves the time on to
+// the next time in the schedule, delayed by a random jitter as for
+// NewCronValSetter. It will panic if the schedule is nil, if the jitter is
+// < 0 or if any of the option functions returns an error.
+func TimeGenCronIntervalF(cs *CronSchedule, jitter time.Duration,
+	opts ...TimeIntervalOptFunc,
+) TimeGenIntervalF {
+	vs := NewCronValSetter(cs, jitter, opts...)
+
+	return func(t time.Time) time.Duration {
+		next := t
+		vs.SetVal(&next)
+
+		return next.Sub(t)
+	}
+}
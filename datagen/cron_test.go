@@ -0,0 +1,157 @@
+package datagen_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// mustParseCron returns the schedule parsed from the expression, failing
+// the test if it cannot be parsed
+func mustParseCron(t *testing.T, expr string) *datagen.CronSchedule {
+	t.Helper()
+
+	cs, err := datagen.ParseCronSchedule(expr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return cs
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	testCases := []struct {
+		name string
+		expr string
+		t    time.Time
+		exp  time.Time
+	}{
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			t:    calTime(1, 10, 7),
+			exp:  calTime(1, 10, 15),
+		},
+		{
+			name: "on the scheduled time",
+			expr: "*/15 * * * *",
+			t:    calTime(1, 10, 15),
+			exp:  calTime(1, 10, 30),
+		},
+		{
+			name: "working hours",
+			expr: "30 9-17 * * MON-FRI",
+			t:    calTime(5, 18, 0),
+			exp:  calTime(8, 9, 30),
+		},
+		{
+			name: "macro",
+			expr: "@daily",
+			t:    calTime(1, 10, 0),
+			exp:  calTime(2, 0, 0),
+		},
+	}
+
+	for _, tc := range testCases {
+		cs := mustParseCron(t, tc.expr)
+		if got := cs.Next(tc.t); !got.Equal(tc.exp) {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.exp, got)
+		}
+	}
+}
+
+func TestParseCronScheduleBadExpr(t *testing.T) {
+	testCases := []struct {
+		name   string
+		expr   string
+		expErr string
+	}{
+		{
+			name:   "too few fields",
+			expr:   "* * * *",
+			expErr: "4 fields found, 5 expected",
+		},
+		{
+			name:   "out of range",
+			expr:   "60 * * * *",
+			expErr: "the minute (60) must be between 0 and 59",
+		},
+		{
+			name:   "never fires",
+			expr:   "0 0 31 FEB *",
+			expErr: "it never fires",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := datagen.ParseCronSchedule(tc.expr)
+		if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+			t.Errorf("%s: an error containing %q was expected, got: %v",
+				tc.name, tc.expErr, err)
+		}
+	}
+}
+
+func TestCronValSetterNoJitter(t *testing.T) {
+	vs := datagen.NewCronValSetter(mustParseCron(t, "*/15 * * * *"), 0)
+	tm := calTime(1, 10, 7)
+
+	for _, exp := range []time.Time{
+		calTime(1, 10, 15), calTime(1, 10, 30), calTime(1, 10, 45),
+		calTime(1, 11, 0),
+	} {
+		vs.SetVal(&tm)
+
+		if !tm.Equal(exp) {
+			t.Errorf("expected %s, got %s", exp, tm)
+		}
+	}
+}
+
+// TestCronValSetterJitter checks that a jitter larger than the gap between
+// the scheduled times doesn't take a time past the next scheduled time, so
+// the times stay in order
+func TestCronValSetterJitter(t *testing.T) {
+	vs := datagen.NewCronValSetter(mustParseCron(t, "*/15 * * * *"),
+		30*time.Minute, intervalSeed())
+	tm := calTime(1, 10, 7)
+	sched := calTime(1, 10, 15)
+	delayed := 0
+
+	for range 10000 {
+		vs.SetVal(&tm)
+
+		if tm.Before(sched) || !tm.Before(sched.Add(15*time.Minute)) {
+			t.Fatalf("%s is not between %s and the next scheduled time",
+				tm, sched)
+		}
+
+		if !tm.Equal(sched) {
+			delayed++
+		}
+
+		sched = sched.Add(15 * time.Minute)
+	}
+
+	if delayed == 0 {
+		t.Error("none of the times were delayed")
+	}
+}
+
+func TestTimeGenCronIntervalF(t *testing.T) {
+	times := arrivals(
+		datagen.TimeGenCronIntervalF(mustParseCron(t, "0 9 * * MON-FRI"),
+			time.Hour, intervalSeed()),
+		calTime(1, 0, 0), 10)
+
+	for i, at := range times {
+		day := calTime(1, 9, 0).AddDate(0, 0, i+i/5*2)
+
+		if at.Before(day) || !at.Before(day.Add(time.Hour)) {
+			t.Errorf("time %d: expected a time in the hour from %s, got %s",
+				i, day, at)
+		}
+	}
+}
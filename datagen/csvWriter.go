@@ -0,0 +1,170 @@
+package datagen
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// CSVWriter records the information needed to write the values generated
+// by a Record as comma-separated values (as described in RFC 4180). The
+// delimiter can be changed so it can also write tab-separated values or
+// values separated by some other character.
+type CSVWriter struct {
+	delim      rune
+	useCRLF    bool
+	showTitles bool
+}
+
+// CSVWriterOptFunc is the type of an option-setting function that will set
+// a value in a CSVWriter
+type CSVWriterOptFunc func(cw *CSVWriter) error
+
+// CSVWriterSetDelimiter returns a CSVWriter Opt function which sets the
+// character used to separate the fields. The default is a comma. It is an
+// error if the delimiter is a quote, a carriage return, a line feed or an
+// invalid rune.
+func CSVWriterSetDelimiter(d rune) CSVWriterOptFunc {
+	return func(cw *CSVWriter) error {
+		if d == '"' || d == '\r' || d == '\n' ||
+			!utf8.ValidRune(d) || d == utf8.RuneError {
+			return fmt.Errorf("bad CSV delimiter: %q", d)
+		}
+
+		cw.delim = d
+
+		return nil
+	}
+}
+
+// CSVWriterSetCRLF returns a CSVWriter Opt function which sets whether or
+// not each line should be terminated with a carriage return, line feed
+// pair. RFC 4180 specifies that lines end with CRLF but the default is to
+// end lines with a single line feed.
+func CSVWriterSetCRLF(useCRLF bool) CSVWriterOptFunc {
+	return func(cw *CSVWriter) error {
+		cw.useCRLF = useCRLF
+		return nil
+	}
+}
+
+// CSVWriterSetShowTitles returns a CSVWriter Opt function which sets whether
+// or not a title row, giving the field names, should be written before the
+// generated rows. The default is to show the titles.
+func CSVWriterSetShowTitles(showTitles bool) CSVWriterOptFunc {
+	return func(cw *CSVWriter) error {
+		cw.showTitles = showTitles
+		return nil
+	}
+}
+
+// NewCSVWriter creates a new CSVWriter object. By default it writes
+// comma-separated values with a title row. It will panic if any of the
+// option functions returns an error.
+func NewCSVWriter(opts ...CSVWriterOptFunc) *CSVWriter {
+	cw := &CSVWriter{
+		delim:      ',',
+		showTitles: true,
+	}
+
+	for _, o := range opts {
+		if err := o(cw); err != nil {
+			panic(err)
+		}
+	}
+
+	return cw
+}
+
+// NewTSVWriter creates a new CSVWriter object which will write tab-separated
+// values. Any options supplied are applied after the delimiter has been set
+// to a tab. It will panic if any of the option functions returns an error.
+func NewTSVWriter(opts ...CSVWriterOptFunc) *CSVWriter {
+	return NewCSVWriter(
+		append([]CSVWriterOptFunc{CSVWriterSetDelimiter('\t')}, opts...)...)
+}
+
+// csvRowWriter writes the rows of a Record as CSV. It implements the
+// RowWriter interface.
+type csvRowWriter struct {
+	csvW       *csv.Writer
+	r          *Record
+	showTitles bool
+}
+
+// writeTitles writes the title row if it is needed and has not yet been
+// written
+func (crw *csvRowWriter) writeTitles() error {
+	if !crw.showTitles {
+		return nil
+	}
+
+	crw.showTitles = false
+
+	return crw.csvW.Write(crw.r.GenerateTitles())
+}
+
+// WriteRow writes the titles (if required and not yet written) and then a
+// row generated from the current values of the Record. Any error
+// encountered while writing, or reported by the Record, is returned.
+func (crw *csvRowWriter) WriteRow() error {
+	if err := crw.writeTitles(); err != nil {
+		return err
+	}
+
+	row := crw.r.Generate()
+
+	if err := crw.r.Err(); err != nil {
+		crw.csvW.Flush()
+		return err
+	}
+
+	return crw.csvW.Write(row)
+}
+
+// Close writes the titles (if required and not yet written) and flushes the
+// output. CSV has no ending so Close is the same whether or not this is the
+// last part of the output.
+func (crw *csvRowWriter) Close() error {
+	if err := crw.writeTitles(); err != nil {
+		return err
+	}
+
+	crw.csvW.Flush()
+
+	return crw.csvW.Error()
+}
+
+// NewRowWriter returns a RowWriter which will write the rows of the Record
+// to the io.Writer.
+func (cw CSVWriter) NewRowWriter(w io.Writer, r *Record) RowWriter {
+	return cw.NewPartRowWriter(w, r, 0, true)
+}
+
+// NewPartRowWriter returns a RowWriter which will write part of the rows of
+// the Record to the io.Writer (see RecordWriter). The titles are only
+// written for the part starting at row 0.
+func (cw CSVWriter) NewPartRowWriter(w io.Writer, r *Record,
+	firstRow int, _ bool,
+) RowWriter {
+	csvW := csv.NewWriter(w)
+	csvW.Comma = cw.delim
+	csvW.UseCRLF = cw.useCRLF
+
+	return &csvRowWriter{
+		csvW:       csvW,
+		r:          r,
+		showTitles: cw.showTitles && firstRow == 0,
+	}
+}
+
+// Write writes the titles (if required) and then the given number of rows
+// generated by the Record to the io.Writer. The first row is generated from
+// the current values of the Record which is moved on to its next value
+// after each row (see Record). Values containing the delimiter, quotes or
+// newlines are quoted. Any error encountered while writing, or reported by
+// the Record, is returned.
+func (cw CSVWriter) Write(w io.Writer, r *Record, rows int) error {
+	return writeRows(cw.NewRowWriter(w, r), r, rows)
+}
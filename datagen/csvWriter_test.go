@@ -0,0 +1,98 @@
+package datagen_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// incrGen returns a generator of ints starting at initial and increasing
+// by incr each time
+func incrGen(initial, incr int) *datagen.Gen[int] {
+	return datagen.NewGen(
+		datagen.GenSetValue(initial),
+		datagen.GenSetValSetter[int](datagen.NewIncrementingValSetter(incr)))
+}
+
+func TestCSVWriter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cw      *datagen.CSVWriter
+		rows    int
+		expText string
+	}{
+		{
+			name:    "default",
+			cw:      datagen.NewCSVWriter(),
+			rows:    2,
+			expText: "id,note\n1,\"a, \"\"b\"\"\"\n2,\"a, \"\"b\"\"\"\n",
+		},
+		{
+			name: "no titles, CRLF",
+			cw: datagen.NewCSVWriter(
+				datagen.CSVWriterSetShowTitles(false),
+				datagen.CSVWriterSetCRLF(true)),
+			rows:    2,
+			expText: "1,\"a, \"\"b\"\"\"\r\n2,\"a, \"\"b\"\"\"\r\n",
+		},
+		{
+			name:    "TSV",
+			cw:      datagen.NewTSVWriter(),
+			rows:    1,
+			expText: "id\tnote\n1\t\"a, \"\"b\"\"\"\n",
+		},
+		{
+			name:    "no rows",
+			cw:      datagen.NewCSVWriter(),
+			rows:    0,
+			expText: "id,note\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		r := datagen.NewRecord("r",
+			datagen.NewField("id", incrGen(1, 1)),
+			datagen.NewField("note", datagen.NewConstGen[string](`a, "b"`)))
+
+		var buf bytes.Buffer
+		if err := tc.cw.Write(&buf, r, tc.rows); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if buf.String() != tc.expText {
+			t.Errorf("%s: bad output\nexpected: %q\n     got: %q",
+				tc.name, tc.expText, buf.String())
+		}
+	}
+}
+
+func TestCSVWriterBadDelimiter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a quote delimiter should panic")
+		}
+	}()
+
+	datagen.NewCSVWriter(datagen.CSVWriterSetDelimiter('"'))
+}
+
+// TestCSVWriterContinues checks that a second call carries on from where
+// the first stopped
+func TestCSVWriterContinues(t *testing.T) {
+	r := datagen.NewRecord("r", datagen.NewField("id", incrGen(1, 1)))
+	cw := datagen.NewCSVWriter(datagen.CSVWriterSetShowTitles(false))
+
+	var buf bytes.Buffer
+
+	for range 2 {
+		if err := cw.Write(&buf, r, 2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if exp := "1\n2\n3\n4\n"; buf.String() != exp {
+		t.Errorf("bad output\nexpected: %q\n     got: %q", exp, buf.String())
+	}
+}
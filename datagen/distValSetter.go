@@ -0,0 +1,490 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"golang.org/x/exp/constraints"
+)
+
+// binomialDirectMax is the largest number of trials for which a binomial
+// value is generated directly, by counting successes, rather than by
+// splitting the trials
+const binomialDirectMax = 40
+
+// poissonDirectMax is the largest mean for which a Poisson value is
+// generated directly, by multiplying uniform values, rather than by
+// transformed rejection
+const poissonDirectMax = 10
+
+// DistValSetter implements a ValSetter that will set the passed value to a
+// value taken from a statistical distribution. Use one of the constructors
+// (NewUniformValSetter, NewExponentialValSetter and so on) to create it.
+//
+// Values taken from a continuous distribution are truncated when converted
+// to an integer type. The values can be constrained to lie within bounds
+// (see DistValSetterSetBounds) which avoids overflow when converting values
+// from long-tailed distributions such as the Pareto distribution.
+type DistValSetter[T constraints.Integer | constraints.Float] struct {
+	r      *rand.Rand
+	sample func() float64
+
+	bounded  bool
+	min, max T
+}
+
+// DistValSetterOptFunc is the type of an option-setting function that will
+// set a value in a DistValSetter
+type DistValSetterOptFunc[T constraints.Integer | constraints.Float] func(
+	vs *DistValSetter[T],
+) error
+
+// DistValSetterSetSeeder returns a DistValSetter Opt function which sets
+// the random number generator to one taken from the supplied Seeder. This
+// allows the generated values to be reproduced.
+func DistValSetterSetSeeder[T constraints.Integer | constraints.Float](
+	s *Seeder,
+) DistValSetterOptFunc[T] {
+	return func(vs *DistValSetter[T]) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		vs.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// DistValSetterSetBounds returns a DistValSetter Opt function which
+// constrains the values to lie between the minimum and maximum values.
+// Values outside the bounds are set to the nearest bound.
+func DistValSetterSetBounds[T constraints.Integer | constraints.Float](
+	minimum, maximum T,
+) DistValSetterOptFunc[T] {
+	return func(vs *DistValSetter[T]) error {
+		if minimum > maximum {
+			return fmt.Errorf("the minimum (%v) must be <= the maximum (%v)",
+				minimum, maximum)
+		}
+
+		vs.bounded = true
+		vs.min = minimum
+		vs.max = maximum
+
+		return nil
+	}
+}
+
+// newDistValSetter creates a DistValSetter, applies the options and then
+// makes the sample function using the random number generator. It will
+// panic if any of the option functions returns an error.
+func newDistValSetter[T constraints.Integer | constraints.Float](
+	mkSample func(r *rand.Rand) func() float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	vs := &DistValSetter[T]{}
+
+	for _, o := range opts {
+		if err := o(vs); err != nil {
+			panic(err)
+		}
+	}
+
+	if vs.r == nil {
+		vs.r = NewRand()
+	}
+
+	vs.sample = mkSample(vs.r)
+
+	return vs
+}
+
+// SetVal sets the given value to the next value from the distribution
+func (vs DistValSetter[T]) SetVal(v *T) {
+	x := vs.sample()
+
+	if vs.bounded {
+		x = math.Max(x, float64(vs.min))
+		x = math.Min(x, float64(vs.max))
+	}
+
+	*v = T(x)
+}
+
+// isIntType returns true if T is an integer type
+func isIntType[T constraints.Integer | constraints.Float]() bool {
+	var half T = 1
+	half /= 2
+
+	return half == 0
+}
+
+// NewUniformValSetter creates and returns a DistValSetter giving values
+// uniformly distributed between the minimum and maximum values. For
+// integer types both the minimum and the maximum can be given and they may
+// be equal; for floating point types the maximum is excluded. It will
+// panic if the minimum is greater than the maximum (or, for floating point
+// types, equal to it) or if any of the option functions returns an error.
+func NewUniformValSetter[T constraints.Integer | constraints.Float](
+	minimum, maximum T,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	intType := isIntType[T]()
+
+	switch {
+	case minimum > maximum:
+		panic(fmt.Errorf("the uniform minimum (%v) must be <= the maximum (%v)",
+			minimum, maximum))
+	case minimum == maximum && !intType:
+		panic(fmt.Errorf("the uniform minimum (%v) must be < the maximum (%v)",
+			minimum, maximum))
+	}
+
+	lo, hi := float64(minimum), float64(maximum)
+	if intType {
+		hi++
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 {
+			x := math.Min(lo+r.Float64()*(hi-lo), math.Nextafter(hi, lo))
+			if intType {
+				// round down rather than truncating towards zero so
+				// that negative values are as likely as the others
+				return math.Floor(x)
+			}
+
+			return x
+		}
+	}, opts...)
+}
+
+// NewExponentialValSetter creates and returns a DistValSetter giving
+// exponentially distributed values with the given rate (the mean is
+// 1/rate). This is suitable for times between events or response times. It
+// will panic if the rate is not > 0 or if any of the option functions
+// returns an error.
+func NewExponentialValSetter[T constraints.Integer | constraints.Float](
+	rate float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(rate > 0) {
+		panic(fmt.Errorf("the exponential rate (%g) must be > 0", rate))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 { return r.ExpFloat64() / rate }
+	}, opts...)
+}
+
+// NewPoissonValSetter creates and returns a DistValSetter giving Poisson
+// distributed values with the given mean. This is suitable for counts of
+// events in an interval. It will panic if the mean is not > 0 or if any of
+// the option functions returns an error.
+func NewPoissonValSetter[T constraints.Integer | constraints.Float](
+	mean float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(mean > 0) || math.IsInf(mean, 1) {
+		panic(fmt.Errorf("the Poisson mean (%g) must be > 0 and finite", mean))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 { return poissonSample(r, mean) }
+	}, opts...)
+}
+
+// NewLogNormalValSetter creates and returns a DistValSetter giving
+// log-normally distributed values; the logarithm of the values is normally
+// distributed with the given mean (mu) and standard deviation (sigma). This
+// is suitable for incomes or file sizes. It will panic if sigma is not > 0
+// or if any of the option functions returns an error.
+func NewLogNormalValSetter[T constraints.Integer | constraints.Float](
+	mu, sigma float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(sigma > 0) {
+		panic(fmt.Errorf("the log-normal sigma (%g) must be > 0", sigma))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 { return math.Exp(mu + sigma*r.NormFloat64()) }
+	}, opts...)
+}
+
+// NewParetoValSetter creates and returns a DistValSetter giving Pareto
+// distributed values with the given scale (the minimum value) and shape
+// (alpha). This is suitable for values where a few are very large, such as
+// wealth or city sizes. It will panic if the scale or the shape is not > 0
+// or if any of the option functions returns an error.
+func NewParetoValSetter[T constraints.Integer | constraints.Float](
+	scale, shape float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(scale > 0) {
+		panic(fmt.Errorf("the Pareto scale (%g) must be > 0", scale))
+	}
+
+	if !(shape > 0) {
+		panic(fmt.Errorf("the Pareto shape (%g) must be > 0", shape))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 {
+			return scale / math.Pow(1-r.Float64(), 1/shape)
+		}
+	}, opts...)
+}
+
+// NewZipfValSetter creates and returns a DistValSetter giving Zipf
+// distributed values between 0 and imax with parameters s and v (see
+// rand.NewZipf). This is suitable for popularity ranks. It will panic if s
+// is not > 1, if v is not >= 1 or if any of the option functions returns
+// an error.
+func NewZipfValSetter[T constraints.Integer | constraints.Float](
+	s, v float64, imax uint64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(s > 1) {
+		panic(fmt.Errorf("the Zipf s parameter (%g) must be > 1", s))
+	}
+
+	if !(v >= 1) {
+		panic(fmt.Errorf("the Zipf v parameter (%g) must be >= 1", v))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		z := rand.NewZipf(r, s, v, imax)
+		return func() float64 { return float64(z.Uint64()) }
+	}, opts...)
+}
+
+// NewBinomialValSetter creates and returns a DistValSetter giving
+// binomially distributed values; the number of successes in n trials each
+// with probability p of success. It will panic if n is < 0, if p is not
+// between 0 and 1 or if any of the option functions returns an error.
+func NewBinomialValSetter[T constraints.Integer | constraints.Float](
+	n int, p float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if n < 0 {
+		panic(fmt.Errorf("the binomial number of trials (%d) must be >= 0", n))
+	}
+
+	if err := checkProb(p); err != nil {
+		panic(err)
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 { return float64(binomialSample(r, n, p)) }
+	}, opts...)
+}
+
+// NewTriangularValSetter creates and returns a DistValSetter giving values
+// with a triangular distribution between the minimum and maximum values
+// and peaking at the mode. This is suitable where only the range and the
+// most likely value are known. It will panic if the minimum is not less
+// than the maximum, if the mode is not between them or if any of the
+// option functions returns an error.
+func NewTriangularValSetter[T constraints.Integer | constraints.Float](
+	minimum, mode, maximum float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(minimum < maximum) {
+		panic(fmt.Errorf(
+			"the triangular minimum (%g) must be < the maximum (%g)",
+			minimum, maximum))
+	}
+
+	if mode < minimum || mode > maximum {
+		panic(fmt.Errorf(
+			"the triangular mode (%g) must be between %g and %g",
+			mode, minimum, maximum))
+	}
+
+	rng := maximum - minimum
+	cut := (mode - minimum) / rng
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 {
+			u := r.Float64()
+			if u < cut {
+				return minimum + math.Sqrt(u*rng*(mode-minimum))
+			}
+
+			return maximum - math.Sqrt((1-u)*rng*(maximum-mode))
+		}
+	}, opts...)
+}
+
+// NewBetaValSetter creates and returns a DistValSetter giving values with a
+// beta distribution, with shape parameters alpha and beta, scaled to lie
+// between the minimum and maximum values. This is suitable for proportions
+// or scores. It will panic if alpha or beta is not > 0, if the minimum is
+// not less than the maximum or if any of the option functions returns an
+// error.
+func NewBetaValSetter[T constraints.Integer | constraints.Float](
+	alpha, beta, minimum, maximum float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(alpha > 0) {
+		panic(fmt.Errorf("the beta alpha parameter (%g) must be > 0", alpha))
+	}
+
+	if !(beta > 0) {
+		panic(fmt.Errorf("the beta beta parameter (%g) must be > 0", beta))
+	}
+
+	if !(minimum < maximum) {
+		panic(fmt.Errorf("the beta minimum (%g) must be < the maximum (%g)",
+			minimum, maximum))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 {
+			return minimum + betaSample(r, alpha, beta)*(maximum-minimum)
+		}
+	}, opts...)
+}
+
+// NewGammaValSetter creates and returns a DistValSetter giving values with
+// a gamma distribution with the given shape and scale (the mean is
+// shape*scale). This is suitable for waiting times and claim sizes. It
+// will panic if the shape or the scale is not > 0 or if any of the option
+// functions returns an error.
+func NewGammaValSetter[T constraints.Integer | constraints.Float](
+	shape, scale float64,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if !(shape > 0) {
+		panic(fmt.Errorf("the gamma shape (%g) must be > 0", shape))
+	}
+
+	if !(scale > 0) {
+		panic(fmt.Errorf("the gamma scale (%g) must be > 0", scale))
+	}
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 { return gammaSample(r, shape) * scale }
+	}, opts...)
+}
+
+// gammaSample returns a value from the gamma distribution with the given
+// shape and a scale of 1. It uses the method of Marsaglia and Tsang, "A
+// Simple Method for Generating Gamma Variables".
+func gammaSample(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		return gammaSample(r, shape+1) * math.Pow(1-r.Float64(), 1/shape)
+	}
+
+	d := shape - 1.0/3.0    //nolint:mnd
+	c := 1 / math.Sqrt(9*d) //nolint:mnd
+
+	for {
+		x := r.NormFloat64()
+
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+
+		v = v * v * v
+		u := r.Float64()
+
+		if u < 1-0.0331*x*x*x*x { //nolint:mnd
+			return d * v
+		}
+
+		if math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) { //nolint:mnd
+			return d * v
+		}
+	}
+}
+
+// betaSample returns a value from the beta distribution with the given
+// shape parameters
+func betaSample(r *rand.Rand, alpha, beta float64) float64 {
+	x := gammaSample(r, alpha)
+	y := gammaSample(r, beta)
+
+	return x / (x + y)
+}
+
+// binomialSample returns a value from the binomial distribution with n
+// trials and probability of success p. Large numbers of trials are split
+// using the distribution of the order statistics of uniform values (see
+// Knuth, TAOCP Vol 2, 3.4.1) until few enough remain to count directly.
+func binomialSample(r *rand.Rand, n int, p float64) int {
+	k := 0
+
+	for n > binomialDirectMax && p > 0 && p < 1 {
+		a := 1 + n/2 //nolint:mnd
+		b := n + 1 - a
+
+		x := betaSample(r, float64(a), float64(b))
+		if x >= p {
+			n = a - 1
+			p /= x
+		} else {
+			k += a
+			n = b - 1
+			p = (p - x) / (1 - x)
+		}
+	}
+
+	for range n {
+		if r.Float64() < p {
+			k++
+		}
+	}
+
+	return k
+}
+
+// poissonSample returns a value from the Poisson distribution with the
+// given mean. Small means use Knuth's multiplication method, larger ones
+// use the transformed rejection method of Hörmann, "The transformed
+// rejection method for generating Poisson random variables".
+func poissonSample(r *rand.Rand, mean float64) float64 {
+	if mean < poissonDirectMax {
+		limit := math.Exp(-mean)
+		k := 0.0
+
+		for p := r.Float64(); p > limit; p *= r.Float64() {
+			k++
+		}
+
+		return k
+	}
+
+	slam := math.Sqrt(mean)
+	logLam := math.Log(mean)
+	b := 0.931 + 2.53*slam              //nolint:mnd
+	a := -0.059 + 0.02483*b             //nolint:mnd
+	invAlpha := 1.1239 + 1.1328/(b-3.4) //nolint:mnd
+	vr := 0.9277 - 3.6224/(b-2)         //nolint:mnd
+
+	for {
+		u := r.Float64() - 0.5 //nolint:mnd
+		v := r.Float64()
+		us := 0.5 - math.Abs(u)                     //nolint:mnd
+		k := math.Floor((2*a/us+b)*u + mean + 0.43) //nolint:mnd
+
+		if us >= 0.07 && v <= vr { //nolint:mnd
+			return k
+		}
+
+		if k < 0 || (us < 0.013 && v > us) { //nolint:mnd
+			continue
+		}
+
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invAlpha)-math.Log(a/(us*us)+b) <=
+			-mean+k*logLam-lg {
+			return k
+		}
+	}
+}
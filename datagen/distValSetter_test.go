@@ -0,0 +1,222 @@
+package datagen_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// distSeed returns the seed option used by the distribution tests
+func distSeed[T int | float64]() datagen.DistValSetterOptFunc[T] {
+	return datagen.DistValSetterSetSeeder[T](datagen.NewSeeder(42))
+}
+
+// sampleStats returns the mean, minimum and maximum of n values from the
+// ValSetter
+func sampleStats[T int | float64](vs datagen.ValSetter[T], n int) (
+	mean float64, lo, hi T,
+) {
+	var v T
+
+	vs.SetVal(&v)
+	lo, hi = v, v
+	tot := 0.0
+
+	for range n {
+		vs.SetVal(&v)
+		tot += float64(v)
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	return tot / float64(n), lo, hi
+}
+
+func TestDistValSetterMeans(t *testing.T) {
+	const draws = 100000
+
+	testCases := []struct {
+		name    string
+		vs      *datagen.DistValSetter[float64]
+		expMean float64
+		tol     float64
+		expMin  float64
+		expMax  float64
+	}{
+		{
+			name:    "uniform",
+			vs:      datagen.NewUniformValSetter(2.0, 4.0, distSeed[float64]()),
+			expMean: 3, tol: 0.02, expMin: 2, expMax: 4,
+		},
+		{
+			name:    "exponential",
+			vs:      datagen.NewExponentialValSetter(0.5, distSeed[float64]()),
+			expMean: 2, tol: 0.05, expMin: 0, expMax: math.Inf(1),
+		},
+		{
+			name:    "Poisson, small mean",
+			vs:      datagen.NewPoissonValSetter(3, distSeed[float64]()),
+			expMean: 3, tol: 0.05, expMin: 0, expMax: math.Inf(1),
+		},
+		{
+			name:    "Poisson, large mean",
+			vs:      datagen.NewPoissonValSetter(200, distSeed[float64]()),
+			expMean: 200, tol: 0.5, expMin: 0, expMax: math.Inf(1),
+		},
+		{
+			name:    "log-normal",
+			vs:      datagen.NewLogNormalValSetter(0, 0.5, distSeed[float64]()),
+			expMean: math.Exp(0.125), tol: 0.02, expMin: 0, expMax: math.Inf(1),
+		},
+		{
+			name:    "Pareto",
+			vs:      datagen.NewParetoValSetter(1, 3, distSeed[float64]()),
+			expMean: 1.5, tol: 0.05, expMin: 1, expMax: math.Inf(1),
+		},
+		{
+			name:    "binomial, few trials",
+			vs:      datagen.NewBinomialValSetter(20, 0.3, distSeed[float64]()),
+			expMean: 6, tol: 0.05, expMin: 0, expMax: 20,
+		},
+		{
+			name:    "binomial, many trials",
+			vs:      datagen.NewBinomialValSetter(1000, 0.1, distSeed[float64]()),
+			expMean: 100, tol: 0.3, expMin: 0, expMax: 1000,
+		},
+		{
+			name: "triangular",
+			vs: datagen.NewTriangularValSetter[float64](0, 3, 6,
+				distSeed[float64]()),
+			expMean: 3, tol: 0.03, expMin: 0, expMax: 6,
+		},
+		{
+			name: "beta",
+			vs: datagen.NewBetaValSetter[float64](2, 6, 0, 100,
+				distSeed[float64]()),
+			expMean: 25, tol: 0.3, expMin: 0, expMax: 100,
+		},
+		{
+			name:    "gamma",
+			vs:      datagen.NewGammaValSetter(0.5, 4, distSeed[float64]()),
+			expMean: 2, tol: 0.05, expMin: 0, expMax: math.Inf(1),
+		},
+		{
+			name:    "Zipf",
+			vs:      datagen.NewZipfValSetter(2, 1, 10, distSeed[float64]()),
+			expMean: 0.938, tol: 0.03, expMin: 0, expMax: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		mean, lo, hi := sampleStats[float64](tc.vs, draws)
+
+		if math.Abs(mean-tc.expMean) > tc.tol {
+			t.Errorf("%s: expected a mean of about %g, got %g",
+				tc.name, tc.expMean, mean)
+		}
+
+		if lo < tc.expMin || hi > tc.expMax {
+			t.Errorf("%s: values from %g to %g are outside [%g, %g]",
+				tc.name, lo, hi, tc.expMin, tc.expMax)
+		}
+	}
+}
+
+func TestDistValSetterInts(t *testing.T) {
+	_, lo, hi := sampleStats[int](
+		datagen.NewUniformValSetter(1, 6, distSeed[int]()), 10000)
+	if lo != 1 || hi != 6 {
+		t.Errorf("uniform ints: expected values from 1 to 6, got %d to %d",
+			lo, hi)
+	}
+
+	_, lo, hi = sampleStats[int](
+		datagen.NewUniformValSetter(7, 7, distSeed[int]()), 100)
+	if lo != 7 || hi != 7 {
+		t.Errorf("uniform ints, min == max: expected only 7, got %d to %d",
+			lo, hi)
+	}
+
+	_, lo, hi = sampleStats[int](
+		datagen.NewParetoValSetter(1, 0.5, distSeed[int](),
+			datagen.DistValSetterSetBounds(1, 1000)), 10000)
+	if lo < 1 || hi != 1000 {
+		t.Errorf("bounded Pareto: expected values from 1 to 1000,"+
+			" got %d to %d", lo, hi)
+	}
+}
+
+// TestUniformValSetterNegativeInts checks that, for a range of integers
+// including negative values, every value is about as likely as the others
+func TestUniformValSetterNegativeInts(t *testing.T) {
+	const (
+		draws  = 110000
+		perVal = draws / 11
+	)
+
+	vs := datagen.NewUniformValSetter(-5, 5, distSeed[int]())
+	counts := map[int]int{}
+
+	var v int
+
+	for range draws {
+		vs.SetVal(&v)
+		counts[v]++
+	}
+
+	if len(counts) != 11 {
+		t.Errorf("expected the values -5 to 5, got: %v", counts)
+	}
+
+	for i := -5; i <= 5; i++ {
+		if d := counts[i] - perVal; d < -perVal/20 || d > perVal/20 {
+			t.Errorf("%d: expected about %d values, got %d",
+				i, perVal, counts[i])
+		}
+	}
+}
+
+func TestDistValSetterBadArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{name: "uniform min == max, floats", f: func() {
+			datagen.NewUniformValSetter(1.0, 1.0)
+		}},
+		{name: "uniform min > max, ints", f: func() {
+			datagen.NewUniformValSetter(2, 1)
+		}},
+		{name: "exponential rate 0", f: func() {
+			datagen.NewExponentialValSetter[int](0)
+		}},
+		{name: "Poisson mean NaN", f: func() {
+			datagen.NewPoissonValSetter[int](math.NaN())
+		}},
+		{name: "binomial p > 1", f: func() {
+			datagen.NewBinomialValSetter[int](10, 1.5)
+		}},
+		{name: "triangular mode too big", f: func() {
+			datagen.NewTriangularValSetter[int](0, 7, 6)
+		}},
+		{name: "Zipf s == 1", f: func() {
+			datagen.NewZipfValSetter[int](1, 1, 10)
+		}},
+		{name: "bounds min > max", f: func() {
+			datagen.NewGammaValSetter(1, 1, datagen.DistValSetterSetBounds(2, 1))
+		}},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: a panic was expected", tc.name)
+				}
+			}()
+
+			tc.f()
+		}()
+	}
+}
@@ -0,0 +1,214 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// DSTTransition records a change in the UTC offset of a location, such as
+// the start or end of daylight saving time
+type DSTTransition struct {
+	// At is the instant of the change, in the location
+	At time.Time
+	// OffsetBefore and OffsetAfter are the UTC offsets either side of the
+	// change
+	OffsetBefore, OffsetAfter time.Duration
+}
+
+// IsGap returns true if the clocks go forward at the transition, so that
+// some local times are skipped, and false if they go back, so that some
+// local times are repeated.
+func (dt DSTTransition) IsGap() bool {
+	return dt.OffsetAfter > dt.OffsetBefore
+}
+
+// Shift returns the size of the change in the UTC offset
+func (dt DSTTransition) Shift() time.Duration {
+	if dt.IsGap() {
+		return dt.OffsetAfter - dt.OffsetBefore
+	}
+
+	return dt.OffsetBefore - dt.OffsetAfter
+}
+
+// LocalRange returns the range of local (wall clock) times which are
+// skipped (for a gap) or repeated. As some of these local times do not
+// exist in the location they are given as times in UTC having the same
+// date and clock values; the range includes the start but not the end.
+func (dt DSTTransition) LocalRange() (start, end time.Time) {
+	wallBefore := dt.At.UTC().Add(dt.OffsetBefore)
+	wallAfter := dt.At.UTC().Add(dt.OffsetAfter)
+
+	if dt.IsGap() {
+		return wallBefore, wallAfter
+	}
+
+	return wallAfter, wallBefore
+}
+
+// FindDSTTransitions returns the changes in the UTC offset of the location
+// between the from and to times
+func FindDSTTransitions(loc *time.Location, from, to time.Time,
+) []DSTTransition {
+	var dts []DSTTransition
+
+	t := from.In(loc)
+
+	for {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || end.After(to) {
+			return dts
+		}
+
+		_, offBefore := t.Zone()
+		_, offAfter := end.Zone()
+
+		if offBefore != offAfter {
+			dts = append(dts, DSTTransition{
+				At:           end,
+				OffsetBefore: time.Duration(offBefore) * time.Second,
+				OffsetAfter:  time.Duration(offAfter) * time.Second,
+			})
+		}
+
+		t = end
+	}
+}
+
+// DSTKind controls which times a DSTValSetter generates
+type DSTKind int
+
+// These constants give the kinds of time that a DSTValSetter can generate.
+const (
+	// DSTNear gives instants within the window either side of any
+	// transition
+	DSTNear DSTKind = iota
+	// DSTRepeated gives instants whose local times are repeated, when the
+	// clocks go back
+	DSTRepeated
+	// DSTSkipped gives local times which are skipped, when the clocks go
+	// forward. As these times do not exist in the location they are given
+	// as times in UTC with the same date and clock values (see
+	// DSTTransition.LocalRange)
+	DSTSkipped
+)
+
+// DSTValSetter implements a ValSetter that will set the passed value to a
+// random time around one of the DST transitions of a location, so as to
+// test the handling of skipped and repeated local times. The transition is
+// chosen at random for each value.
+type DSTValSetter struct {
+	r      *rand.Rand
+	dts    []DSTTransition
+	kind   DSTKind
+	window time.Duration
+}
+
+// DSTValSetterOptFunc is the type of an option-setting function that will
+// set a value in a DSTValSetter
+type DSTValSetterOptFunc func(vs *DSTValSetter) error
+
+// DSTValSetterSetSeeder returns a DSTValSetter Opt function which sets the
+// random number generator to one taken from the supplied Seeder. This
+// allows the generated times to be reproduced.
+func DSTValSetterSetSeeder(s *Seeder) DSTValSetterOptFunc {
+	return func(vs *DSTValSetter) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		vs.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// DSTValSetterSetKind returns a DSTValSetter Opt function which sets the
+// kind of time generated. The default is DSTNear.
+func DSTValSetterSetKind(kind DSTKind) DSTValSetterOptFunc {
+	return func(vs *DSTValSetter) error {
+		if kind < DSTNear || kind > DSTSkipped {
+			return fmt.Errorf("bad DST kind: %d", kind)
+		}
+
+		vs.kind = kind
+
+		return nil
+	}
+}
+
+// DSTValSetterSetWindow returns a DSTValSetter Opt function which sets the
+// time either side of a transition within which DSTNear instants are
+// generated. The default is two hours.
+func DSTValSetterSetWindow(d time.Duration) DSTValSetterOptFunc {
+	return func(vs *DSTValSetter) error {
+		if d <= 0 {
+			return fmt.Errorf("the window (%s) must be > 0", d)
+		}
+
+		vs.window = d
+
+		return nil
+	}
+}
+
+// NewDSTValSetter creates and returns a DSTValSetter giving times around the
+// DST transitions of the location between the from and to times. It will
+// panic if the location is nil, if there are no transitions of the kind
+// needed or if any of the option functions returns an error.
+func NewDSTValSetter(loc *time.Location, from, to time.Time,
+	opts ...DSTValSetterOptFunc,
+) *DSTValSetter {
+	if loc == nil {
+		panic(errors.New("a nil location has been supplied"))
+	}
+
+	vs := &DSTValSetter{window: 2 * time.Hour} //nolint:mnd
+
+	for _, o := range opts {
+		if err := o(vs); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, dt := range FindDSTTransitions(loc, from, to) {
+		if (vs.kind == DSTRepeated && dt.IsGap()) ||
+			(vs.kind == DSTSkipped && !dt.IsGap()) {
+			continue
+		}
+
+		vs.dts = append(vs.dts, dt)
+	}
+
+	if len(vs.dts) == 0 {
+		panic(fmt.Errorf("%s has no suitable offset changes between %s and %s",
+			loc, from, to))
+	}
+
+	if vs.r == nil {
+		vs.r = NewRand()
+	}
+
+	return vs
+}
+
+// SetVal sets the time to a random time around a random transition
+func (vs DSTValSetter) SetVal(t *time.Time) {
+	dt := vs.dts[vs.r.IntN(len(vs.dts))]
+
+	switch vs.kind {
+	case DSTRepeated:
+		// the repeated local times start one shift before the transition
+		// and end one shift after it
+		d := time.Duration(vs.r.Int64N(int64(2 * dt.Shift()))) //nolint:mnd
+		*t = dt.At.Add(d - dt.Shift())
+	case DSTSkipped:
+		start, _ := dt.LocalRange()
+		*t = start.Add(time.Duration(vs.r.Int64N(int64(dt.Shift()))))
+	default:
+		d := time.Duration(vs.r.Int64N(int64(2 * vs.window))) //nolint:mnd
+		*t = dt.At.Add(d - vs.window)
+	}
+}
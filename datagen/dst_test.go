@@ -0,0 +1,147 @@
+package datagen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// loadLoc returns the named location, skipping the test if it is not
+// available
+func loadLoc(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("the location is not available: %v", err)
+	}
+
+	return loc
+}
+
+var (
+	dstFrom = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	dstTo   = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestFindDSTTransitions(t *testing.T) {
+	ny := loadLoc(t, "America/New_York")
+
+	dts := datagen.FindDSTTransitions(ny, dstFrom, dstTo)
+	if len(dts) != 2 {
+		t.Fatalf("expected 2 transitions, got %d: %v", len(dts), dts)
+	}
+
+	spring, autumn := dts[0], dts[1]
+	springAt := time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC)
+	autumnAt := time.Date(2024, time.November, 3, 6, 0, 0, 0, time.UTC)
+
+	if !spring.At.Equal(springAt) || !spring.IsGap() ||
+		spring.Shift() != time.Hour {
+		t.Errorf("bad spring transition: %+v", spring)
+	}
+
+	start, end := spring.LocalRange()
+	if start.Hour() != 2 || end.Hour() != 3 {
+		t.Errorf("the skipped local times should be 02:00 to 03:00,"+
+			" got %s to %s", start, end)
+	}
+
+	if !autumn.At.Equal(autumnAt) || autumn.IsGap() ||
+		autumn.Shift() != time.Hour {
+		t.Errorf("bad autumn transition: %+v", autumn)
+	}
+
+	dts = datagen.FindDSTTransitions(time.UTC, dstFrom, dstTo)
+	if len(dts) != 0 {
+		t.Errorf("UTC has no transitions, got: %v", dts)
+	}
+}
+
+func TestDSTValSetter(t *testing.T) {
+	ny := loadLoc(t, "America/New_York")
+	seed := datagen.DSTValSetterSetSeeder(datagen.NewSeeder(42))
+
+	repeated := datagen.NewDSTValSetter(ny, dstFrom, dstTo, seed,
+		datagen.DSTValSetterSetKind(datagen.DSTRepeated))
+	skipped := datagen.NewDSTValSetter(ny, dstFrom, dstTo, seed,
+		datagen.DSTValSetterSetKind(datagen.DSTSkipped))
+	near := datagen.NewDSTValSetter(ny, dstFrom, dstTo, seed,
+		datagen.DSTValSetterSetWindow(30*time.Minute))
+
+	var tm time.Time
+
+	for range 100 {
+		repeated.SetVal(&tm)
+
+		local := tm.In(ny)
+		if local.Month() != time.November || local.Hour() != 1 {
+			t.Errorf("%s (%s) is not a repeated local time", tm, local)
+		}
+
+		skipped.SetVal(&tm)
+
+		if tm.Month() != time.March || tm.Day() != 10 || tm.Hour() != 2 {
+			t.Errorf("%s is not a skipped local time", tm)
+		}
+
+		near.SetVal(&tm)
+
+		dMar := tm.Sub(time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC))
+		dNov := tm.Sub(time.Date(2024, time.November, 3, 6, 0, 0, 0, time.UTC))
+
+		if dMar.Abs() > 30*time.Minute && dNov.Abs() > 30*time.Minute {
+			t.Errorf("%s is not within 30m of a transition", tm)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a panic was expected for a location with no transitions")
+		}
+	}()
+
+	datagen.NewDSTValSetter(time.UTC, dstFrom, dstTo)
+}
+
+func TestTimeGenDisplayLocation(t *testing.T) {
+	ny := loadLoc(t, "America/New_York")
+	tokyo := loadLoc(t, "Asia/Tokyo")
+	start := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	tg := datagen.NewTimeGen(
+		datagen.TimeGenSetInitialTime(start),
+		datagen.TimeGenSetLayout("15:04 MST"),
+		datagen.TimeGenSetDisplayLocation(ny))
+
+	if got := tg.Generate(); got != "08:00 EDT" {
+		t.Errorf("expected the time in New York, got %q", got)
+	}
+
+	if loc := tg.Value().Location(); loc != ny {
+		t.Errorf("expected the value to be in New York, got %s", loc)
+	}
+
+	locs := datagen.NewWeightedGen(datagen.Sequential,
+		[]datagen.WeightedVal[*time.Location]{
+			{Val: tokyo, Weight: 1},
+			{Val: nil, Weight: 1},
+		})
+	tg = datagen.NewTimeGen(
+		datagen.TimeGenSetInitialTime(start),
+		datagen.TimeGenSetLayout("15:04 MST"),
+		datagen.TimeGenSetDisplayLocationGen(locs))
+
+	var got []string
+
+	for range 2 {
+		got = append(got, tg.Generate())
+		tg.Next()
+		locs.Next()
+	}
+
+	if got[0] != "21:00 JST" || got[1] != "12:00 UTC" {
+		t.Errorf("expected the times in Tokyo and then UTC, got %v", got)
+	}
+}
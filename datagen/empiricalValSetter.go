@@ -0,0 +1,138 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+
+	"golang.org/x/exp/constraints"
+)
+
+// NewHistogramValSetter creates and returns a DistValSetter giving values
+// that reproduce the distribution described by the histogram. The bounds
+// give the edges of the buckets, in increasing order, so there must be one
+// more bound than there are counts; the i'th count is the number of values
+// between bounds[i] and bounds[i+1].
+//
+// A bucket is chosen with probability proportional to its count. If
+// interpolate is true the value is then spread uniformly within the bucket
+// (this is inverse-CDF sampling of the piecewise-linear CDF); otherwise the
+// lower bound of the bucket is given, which is useful where each bucket
+// represents a single value.
+//
+// It will panic if the bounds are not increasing, if the numbers of bounds
+// and counts do not match, if any count is < 0, if all the counts are 0 or
+// if any of the option functions returns an error.
+func NewHistogramValSetter[T constraints.Integer | constraints.Float](
+	bounds []float64, counts []int, interpolate bool,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	cum, err := histogramCumCounts(bounds, counts)
+	if err != nil {
+		panic(err)
+	}
+
+	bounds = slices.Clone(bounds)
+	total := float64(cum[len(cum)-1])
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 {
+			u := r.Float64() * total
+			i := sort.Search(len(cum), func(i int) bool {
+				return float64(cum[i]) > u
+			})
+
+			if !interpolate {
+				return bounds[i]
+			}
+
+			prior := 0.0
+			if i > 0 {
+				prior = float64(cum[i-1])
+			}
+
+			frac := (u - prior) / (float64(cum[i]) - prior)
+
+			return bounds[i] + frac*(bounds[i+1]-bounds[i])
+		}
+	}, opts...)
+}
+
+// histogramCumCounts checks the histogram and returns the cumulative counts
+func histogramCumCounts(bounds []float64, counts []int) ([]int, error) {
+	if len(counts) == 0 {
+		return nil, errors.New("the histogram has no buckets")
+	}
+
+	if len(bounds) != len(counts)+1 {
+		return nil, fmt.Errorf(
+			"the histogram has %d counts so it needs %d bounds, not %d",
+			len(counts), len(counts)+1, len(bounds))
+	}
+
+	for i := 1; i < len(bounds); i++ {
+		if !(bounds[i] > bounds[i-1]) {
+			return nil, fmt.Errorf(
+				"the histogram bounds must be increasing:"+
+					" bound[%d] (%g) is not > bound[%d] (%g)",
+				i, bounds[i], i-1, bounds[i-1])
+		}
+	}
+
+	cum := make([]int, 0, len(counts))
+	tot := 0
+
+	for i, c := range counts {
+		if c < 0 {
+			return nil, fmt.Errorf("the histogram count[%d] (%d) is < 0", i, c)
+		}
+
+		tot += c
+		cum = append(cum, tot)
+	}
+
+	if tot == 0 {
+		return nil, errors.New("the histogram counts are all 0")
+	}
+
+	return cum, nil
+}
+
+// NewSampleValSetter creates and returns a DistValSetter giving values
+// that reproduce the distribution of the observed samples. If interpolate
+// is false the values are chosen from the samples, each sample being
+// equally likely. If interpolate is true the values are taken from the
+// empirical CDF of the samples with linear interpolation between adjacent
+// sorted samples, so values between the observed values are also given but
+// never values outside the range of the samples. It will panic if there
+// are no samples or if any of the option functions returns an error.
+func NewSampleValSetter[T constraints.Integer | constraints.Float](
+	samples []T, interpolate bool,
+	opts ...DistValSetterOptFunc[T],
+) *DistValSetter[T] {
+	if len(samples) == 0 {
+		panic(errors.New("no samples have been given"))
+	}
+
+	sorted := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		sorted = append(sorted, float64(s))
+	}
+
+	slices.Sort(sorted)
+
+	return newDistValSetter(func(r *rand.Rand) func() float64 {
+		return func() float64 {
+			if !interpolate || len(sorted) == 1 {
+				return sorted[r.IntN(len(sorted))]
+			}
+
+			p := r.Float64() * float64(len(sorted)-1)
+			i := int(p)
+
+			return sorted[i] + (p-float64(i))*(sorted[i+1]-sorted[i])
+		}
+	}, opts...)
+}
@@ -0,0 +1,169 @@
+package datagen_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+func TestHistogramValSetter(t *testing.T) {
+	const draws = 100000
+
+	bounds := []float64{0, 10, 20, 50}
+	counts := []int{1, 0, 3}
+
+	vs := datagen.NewHistogramValSetter[float64](bounds, counts, false,
+		distSeed[float64]())
+	freq := map[float64]int{}
+
+	var v float64
+
+	for range draws {
+		vs.SetVal(&v)
+		freq[v]++
+	}
+
+	if len(freq) != 2 || freq[10] != 0 {
+		t.Errorf("only the lower bounds of non-empty buckets are expected,"+
+			" got: %v", freq)
+	}
+
+	if p := float64(freq[20]) / draws; math.Abs(p-0.75) > 0.01 {
+		t.Errorf("the last bucket should be chosen 3/4 of the time, got %g",
+			p)
+	}
+
+	mean, lo, hi := sampleStats[float64](
+		datagen.NewHistogramValSetter[float64](bounds, counts, true,
+			distSeed[float64]()), draws)
+	if lo < 0 || hi >= 50 {
+		t.Errorf("interpolated values from %g to %g are outside [0, 50)",
+			lo, hi)
+	}
+
+	// the expected mean is 1/4 of 5 (the middle of the first bucket) plus
+	// 3/4 of 35 (the middle of the last)
+	if math.Abs(mean-27.5) > 0.2 {
+		t.Errorf("expected an interpolated mean of about 27.5, got %g", mean)
+	}
+}
+
+// TestHistogramValSetterCountsChanged checks that changing the counts
+// after the ValSetter is made does not change the values it gives
+func TestHistogramValSetterCountsChanged(t *testing.T) {
+	counts := []int{1, 3}
+	vs := datagen.NewHistogramValSetter[float64]([]float64{0, 10, 20},
+		counts, true, distSeed[float64]())
+	counts[0], counts[1] = 0, 0
+
+	mean, lo, hi := sampleStats[float64](vs, 100000)
+	if lo < 0 || hi >= 20 || math.Abs(mean-12.5) > 0.1 {
+		t.Errorf("expected values in [0, 20) with a mean of about 12.5,"+
+			" got %g to %g with a mean of %g", lo, hi, mean)
+	}
+}
+
+func TestHistogramValSetterBadArgs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		bounds []float64
+		counts []int
+		expErr string
+	}{
+		{
+			name:   "no buckets",
+			bounds: []float64{0},
+			expErr: "no buckets",
+		},
+		{
+			name:   "too few bounds",
+			bounds: []float64{0, 1},
+			counts: []int{1, 2},
+			expErr: "it needs 3 bounds, not 2",
+		},
+		{
+			name:   "bounds not increasing",
+			bounds: []float64{0, 2, 2},
+			counts: []int{1, 2},
+			expErr: "must be increasing",
+		},
+		{
+			name:   "negative count",
+			bounds: []float64{0, 1, 2},
+			counts: []int{1, -2},
+			expErr: "count[1] (-2) is < 0",
+		},
+		{
+			name:   "all zero",
+			bounds: []float64{0, 1},
+			counts: []int{0},
+			expErr: "all 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				p := recover()
+				err, ok := p.(error)
+
+				if !ok || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("%s: a panic containing %q was expected, got: %v",
+						tc.name, tc.expErr, p)
+				}
+			}()
+
+			datagen.NewHistogramValSetter[float64](tc.bounds, tc.counts, true)
+		}()
+	}
+}
+
+func TestSampleValSetter(t *testing.T) {
+	samples := []int{5, 1, 9, 5}
+
+	vs := datagen.NewSampleValSetter(samples, false, distSeed[int]())
+	seen := map[int]int{}
+
+	var v int
+
+	for range 10000 {
+		vs.SetVal(&v)
+		seen[v]++
+	}
+
+	if len(seen) != 3 || seen[5] < 4500 || seen[5] > 5500 {
+		t.Errorf("expected 1, 5 and 9 with 5 half the time, got: %v", seen)
+	}
+
+	ivs := datagen.NewSampleValSetter([]float64{1, 5, 5, 9}, true,
+		distSeed[float64]())
+	between := 0
+
+	var f float64
+
+	for range 1000 {
+		ivs.SetVal(&f)
+
+		if f < 1 || f > 9 {
+			t.Errorf("the interpolated value %g is outside [1, 9]", f)
+		}
+
+		if f != math.Trunc(f) {
+			between++
+		}
+	}
+
+	if between == 0 {
+		t.Error("no values between the samples were given")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a panic was expected for no samples")
+		}
+	}()
+
+	datagen.NewSampleValSetter([]int{}, true)
+}
@@ -0,0 +1,295 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+)
+
+// ErrNoKeys is the error reported by a ForeignKeyGen when the KeyPool it
+// draws from is empty.
+var ErrNoKeys = errors.New("there are no keys to choose from")
+
+// KeyPool wraps a TypedGenerator and records every value it generates. It
+// is used as the field Generator in the Record holding the keys (the parent
+// table) and the recorded values are then available to any ForeignKeyGen
+// in other Records. A value is recorded the first time it is generated (by
+// a call to Generate or Value) so only the keys actually written are
+// recorded. The string form of each key is recorded with it so that a
+// ForeignKeyGen gives the key exactly as it was written in the parent
+// table. The Record holding the keys should be generated first. It
+// implements the TypedGenerator interface.
+type KeyPool[T any] struct {
+	tg       TypedGenerator[T]
+	keys     []T
+	strs     []string
+	recorded bool
+}
+
+// NewKeyPool creates a new, empty, KeyPool wrapping the TypedGenerator. It
+// will panic if the TypedGenerator is nil.
+func NewKeyPool[T any](tg TypedGenerator[T]) *KeyPool[T] {
+	if tg == nil {
+		panic(errors.New("a nil TypedGenerator has been supplied"))
+	}
+
+	return &KeyPool[T]{tg: tg}
+}
+
+// record adds the current value and its string form to the keys if they
+// have not been added already
+func (kp *KeyPool[T]) record() {
+	if !kp.recorded {
+		kp.keys = append(kp.keys, kp.tg.Value())
+		kp.strs = append(kp.strs, kp.tg.Generate())
+		kp.recorded = true
+	}
+}
+
+// Generate records the current value and returns its string form
+func (kp *KeyPool[T]) Generate() string {
+	kp.record()
+
+	return kp.strs[len(kp.strs)-1]
+}
+
+// Value records the current value and returns it
+func (kp *KeyPool[T]) Value() T {
+	kp.record()
+
+	return kp.tg.Value()
+}
+
+// Next moves the wrapped TypedGenerator on to its next value
+func (kp *KeyPool[T]) Next() {
+	kp.tg.Next()
+	kp.recorded = false
+}
+
+// Len returns the number of keys recorded
+func (kp KeyPool[T]) Len() int {
+	return len(kp.keys)
+}
+
+// Keys returns the keys recorded
+func (kp KeyPool[T]) Keys() []T {
+	return kp.keys
+}
+
+// ===================================================================
+
+// keyChooser is the interface describing how a ForeignKeyGen chooses the
+// index of the next key from the KeyPool.
+type keyChooser[T any] interface {
+	choose(r *rand.Rand, kp *KeyPool[T]) int
+}
+
+// uniformChooser chooses each key with equal probability
+type uniformChooser[T any] struct{}
+
+// choose returns a random index
+func (uniformChooser[T]) choose(r *rand.Rand, kp *KeyPool[T]) int {
+	return r.IntN(kp.Len())
+}
+
+// weightedChooser chooses each key with probability proportional to the
+// weight given to it by the weight function. The cumulative weights are
+// extended as the KeyPool grows.
+type weightedChooser[T any] struct {
+	weight    func(T) float64
+	cumWeight []float64
+}
+
+// choose returns a random index chosen in proportion to the weights
+func (wc *weightedChooser[T]) choose(r *rand.Rand, kp *KeyPool[T]) int {
+	tot := 0.0
+	if n := len(wc.cumWeight); n > 0 {
+		tot = wc.cumWeight[n-1]
+	}
+
+	for _, k := range kp.keys[len(wc.cumWeight):] {
+		if w := wc.weight(k); w > 0 {
+			tot += w
+		}
+
+		wc.cumWeight = append(wc.cumWeight, tot)
+	}
+
+	if tot == 0 {
+		return r.IntN(kp.Len())
+	}
+
+	target := r.Float64() * tot
+
+	return sort.Search(len(wc.cumWeight), func(i int) bool {
+		return wc.cumWeight[i] > target
+	})
+}
+
+// zipfChooser chooses keys following a Zipf distribution so that the
+// earliest keys are chosen far more often than the later ones.
+type zipfChooser[T any] struct {
+	s, v float64
+	z    *rand.Zipf
+	n    int
+}
+
+// choose returns a Zipf-distributed index
+func (zc *zipfChooser[T]) choose(r *rand.Rand, kp *KeyPool[T]) int {
+	if zc.z == nil || zc.n != kp.Len() {
+		zc.n = kp.Len()
+		zc.z = rand.NewZipf(r, zc.s, zc.v, uint64(zc.n-1)) //nolint:gosec
+	}
+
+	return int(zc.z.Uint64()) //nolint:gosec
+}
+
+// ForeignKeyGen generates values chosen from the keys recorded by a
+// KeyPool. By default the keys are chosen uniformly. If the KeyPool is empty
+// the zero value is given and the error is reported by the Err method. It
+// implements the TypedGenerator and ErrReporter interfaces.
+type ForeignKeyGen[T any] struct {
+	kp      *KeyPool[T]
+	r       *rand.Rand
+	chooser keyChooser[T]
+	idx     int
+	err     error
+}
+
+// ForeignKeyGenOptFunc is the type of an option-setting function that will
+// set a value in a ForeignKeyGen
+type ForeignKeyGenOptFunc[T any] func(fk *ForeignKeyGen[T]) error
+
+// ForeignKeyGenSetSeeder returns a ForeignKeyGen Opt function which sets the
+// random number generator to one taken from the supplied Seeder.
+func ForeignKeyGenSetSeeder[T any](s *Seeder) ForeignKeyGenOptFunc[T] {
+	return func(fk *ForeignKeyGen[T]) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		fk.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// ForeignKeyGenSetWeights returns a ForeignKeyGen Opt function which makes
+// the keys be chosen with a probability proportional to the weight given by
+// the function. Keys with a weight <= 0 are never chosen (unless all the
+// weights are <= 0 in which case the keys are chosen uniformly).
+func ForeignKeyGenSetWeights[T any](f func(T) float64) ForeignKeyGenOptFunc[T] {
+	return func(fk *ForeignKeyGen[T]) error {
+		if f == nil {
+			return errors.New("a nil weight function has been supplied")
+		}
+
+		fk.chooser = &weightedChooser[T]{weight: f}
+
+		return nil
+	}
+}
+
+// ForeignKeyGenSetZipf returns a ForeignKeyGen Opt function which makes the
+// keys be chosen following a Zipf distribution with parameters s and v (see
+// rand.NewZipf). The first key recorded is the most likely to be chosen,
+// the second the next most likely and so on. The value of s must be > 1 and
+// v must be >= 1.
+func ForeignKeyGenSetZipf[T any](s, v float64) ForeignKeyGenOptFunc[T] {
+	return func(fk *ForeignKeyGen[T]) error {
+		if s <= 1 {
+			return fmt.Errorf("the Zipf s parameter (%g) must be > 1", s)
+		}
+
+		if v < 1 {
+			return fmt.Errorf("the Zipf v parameter (%g) must be >= 1", v)
+		}
+
+		fk.chooser = &zipfChooser[T]{s: s, v: v}
+
+		return nil
+	}
+}
+
+// NewForeignKeyGen creates a new ForeignKeyGen choosing keys from the
+// KeyPool. The first key is chosen when the value is first needed so the
+// KeyPool need not be populated until then. It will panic if the KeyPool is
+// nil or if any of the option functions returns an error.
+func NewForeignKeyGen[T any](
+	kp *KeyPool[T], opts ...ForeignKeyGenOptFunc[T],
+) *ForeignKeyGen[T] {
+	if kp == nil {
+		panic(errors.New("a nil KeyPool has been supplied"))
+	}
+
+	fk := &ForeignKeyGen[T]{
+		kp:      kp,
+		chooser: uniformChooser[T]{},
+		idx:     -1,
+	}
+
+	for _, o := range opts {
+		if err := o(fk); err != nil {
+			panic(err)
+		}
+	}
+
+	if fk.r == nil {
+		fk.r = NewRand()
+	}
+
+	return fk
+}
+
+// choose sets the index of the chosen key if it has not been set already.
+// It returns false if there are no keys.
+func (fk *ForeignKeyGen[T]) choose() bool {
+	if fk.idx >= 0 {
+		return true
+	}
+
+	if fk.kp.Len() == 0 {
+		if fk.err == nil {
+			fk.err = ErrNoKeys
+		}
+
+		return false
+	}
+
+	fk.idx = fk.chooser.choose(fk.r, fk.kp)
+
+	return true
+}
+
+// Value returns the chosen key
+func (fk *ForeignKeyGen[T]) Value() T {
+	if !fk.choose() {
+		var zero T
+		return zero
+	}
+
+	return fk.kp.keys[fk.idx]
+}
+
+// Generate returns the chosen key as it was generated by the KeyPool, so
+// it is formatted in the same way as in the Record holding the keys
+func (fk *ForeignKeyGen[T]) Generate() string {
+	if !fk.choose() {
+		return ""
+	}
+
+	return fk.kp.strs[fk.idx]
+}
+
+// Next discards the chosen key so that a new one is chosen when the value is
+// next needed
+func (fk *ForeignKeyGen[T]) Next() {
+	fk.idx = -1
+}
+
+// Err returns the error recorded if a key was needed and the KeyPool was
+// empty
+func (fk ForeignKeyGen[T]) Err() error {
+	return fk.err
+}
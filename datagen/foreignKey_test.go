@@ -0,0 +1,149 @@
+package datagen_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// keyPool returns a KeyPool holding the keys 1 to n, recorded by writing
+// the parent Record
+func keyPool(t *testing.T, n int) *datagen.KeyPool[int] {
+	t.Helper()
+
+	kp := datagen.NewKeyPool[int](incrGen(1, 1))
+
+	var buf bytes.Buffer
+	if err := datagen.NewCSVWriter().Write(&buf,
+		datagen.NewRecord("parent", datagen.NewField("id", kp)), n); err != nil {
+		t.Fatalf("unexpected error writing the parent: %v", err)
+	}
+
+	return kp
+}
+
+func TestKeyPool(t *testing.T) {
+	kp := keyPool(t, 5)
+
+	if exp := []int{1, 2, 3, 4, 5}; !slices.Equal(kp.Keys(), exp) {
+		t.Errorf("bad keys\nexpected: %v\n     got: %v", exp, kp.Keys())
+	}
+}
+
+// fkCounts returns the number of times each key is chosen in n values
+func fkCounts(t *testing.T,
+	fk *datagen.ForeignKeyGen[int], n int,
+) map[int]int {
+	t.Helper()
+
+	counts := map[int]int{}
+
+	for range n {
+		v := fk.Value()
+		if v != fk.Value() {
+			t.Fatal("the value changed without a call to Next")
+		}
+
+		counts[v]++
+
+		fk.Next()
+	}
+
+	return counts
+}
+
+func TestForeignKeyGen(t *testing.T) {
+	const draws = 10000
+
+	kp := keyPool(t, 4)
+
+	uniform := fkCounts(t, datagen.NewForeignKeyGen(kp,
+		datagen.ForeignKeyGenSetSeeder[int](datagen.NewSeeder(42))), draws)
+	for k := 1; k <= 4; k++ {
+		if c := uniform[k]; c < 2300 || c > 2700 {
+			t.Errorf("uniform: key %d chosen %d times, expected about 2500",
+				k, c)
+		}
+	}
+
+	weighted := fkCounts(t, datagen.NewForeignKeyGen(kp,
+		datagen.ForeignKeyGenSetSeeder[int](datagen.NewSeeder(42)),
+		datagen.ForeignKeyGenSetWeights(func(k int) float64 {
+			if k == 4 {
+				return 0
+			}
+
+			return float64(k)
+		})), draws)
+	if weighted[4] != 0 {
+		t.Errorf("weighted: key 4 has zero weight but was chosen %d times",
+			weighted[4])
+	}
+
+	if c := weighted[3]; c < 4700 || c > 5300 {
+		t.Errorf("weighted: key 3 chosen %d times, expected about 5000", c)
+	}
+
+	zipf := fkCounts(t, datagen.NewForeignKeyGen(kp,
+		datagen.ForeignKeyGenSetSeeder[int](datagen.NewSeeder(42)),
+		datagen.ForeignKeyGenSetZipf[int](2, 1)), draws)
+	if zipf[1] <= zipf[2] || zipf[2] <= zipf[4] {
+		t.Errorf("zipf: the earlier keys should be chosen more often: %v",
+			zipf)
+	}
+}
+
+func TestForeignKeyGenNoKeys(t *testing.T) {
+	kp := datagen.NewKeyPool[int](incrGen(1, 1))
+	r := datagen.NewRecord("child",
+		datagen.NewField("parent", datagen.NewForeignKeyGen(kp)))
+
+	var buf bytes.Buffer
+
+	err := datagen.NewCSVWriter().Write(&buf, r, 1)
+	if !errors.Is(err, datagen.ErrNoKeys) {
+		t.Errorf("expected ErrNoKeys, got: %v", err)
+	}
+}
+
+// TestForeignKeyGenFormat checks that the foreign keys are written exactly
+// as the keys in the parent, using the parent's layout
+func TestForeignKeyGenFormat(t *testing.T) {
+	kp := datagen.NewKeyPool[time.Time](datagen.NewTimeGen(
+		datagen.TimeGenSetInitialTime(time.Date(2024, time.January, 1,
+			9, 0, 0, 0, time.UTC)),
+		datagen.TimeGenSetLayout("02/01/2006 15:04"),
+		datagen.TimeGenSetIntervalF(
+			datagen.TimeGenConstIntervalF(time.Hour))))
+
+	var parent, child bytes.Buffer
+
+	w := datagen.NewCSVWriter(datagen.CSVWriterSetShowTitles(false))
+	if err := w.Write(&parent,
+		datagen.NewRecord("parent", datagen.NewField("at", kp)), 3); err != nil {
+		t.Fatalf("unexpected error writing the parent: %v", err)
+	}
+
+	fk := datagen.NewForeignKeyGen(kp,
+		datagen.ForeignKeyGenSetSeeder[time.Time](datagen.NewSeeder(42)))
+	if err := w.Write(&child,
+		datagen.NewRecord("child", datagen.NewField("at", fk)), 20); err != nil {
+		t.Fatalf("unexpected error writing the child: %v", err)
+	}
+
+	keys := strings.Split(strings.TrimSpace(parent.String()), "\n")
+	if keys[0] != "01/01/2024 09:00" {
+		t.Fatalf("bad parent key: %q", keys[0])
+	}
+
+	for _, k := range strings.Split(strings.TrimSpace(child.String()), "\n") {
+		if !slices.Contains(keys, k) {
+			t.Errorf("the foreign key %q is not a parent key: %v", k, keys)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package datagen
 
+import "reflect"
+
 // Generator describes the methods that a field generator must provide.
 type Generator interface {
 	Generate() string
@@ -17,3 +19,36 @@ type TypedGenerator[T any] interface {
 	Generator
 	TypedVal[T]
 }
+
+// Nullable represents a method that reports whether the current value of a
+// generator should be treated as missing (NULL) rather than as a value.
+type Nullable interface {
+	IsNull() bool
+}
+
+// ErrReporter represents a method that reports any error encountered while
+// moving a generator on to its next value.
+type ErrReporter interface {
+	Err() error
+}
+
+// isNull returns true if the Generator implements the Nullable interface and
+// its current value is NULL.
+func isNull(g Generator) bool {
+	n, ok := g.(Nullable)
+
+	return ok && n.IsNull()
+}
+
+// typedValue returns the value given by the Value method of the Generator
+// and true if it has one. Otherwise it returns nil and false. Reflection is
+// used so that any TypedGenerator can be handled regardless of its type
+// parameter.
+func typedValue(g Generator) (any, bool) {
+	m := reflect.ValueOf(g).MethodByName("Value")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil, false
+	}
+
+	return m.Call(nil)[0].Interface(), true
+}
@@ -0,0 +1,278 @@
+package datagen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+// MoneyJSONFormat encodes how Money values should be represented in JSON
+type MoneyJSONFormat int
+
+// MoneyJSONDecimal means that a Money value should be given as a JSON
+// number with the appropriate number of decimal places: 1.23
+//
+// MoneyJSONObject means that a Money value should be given as a JSON object
+// holding the amount as a decimal number and the ISO 4217 currency code:
+// {"amount":1.23,"currency":"USD"}
+const (
+	MoneyJSONDecimal MoneyJSONFormat = iota
+	MoneyJSONObject
+)
+
+// JSONWriter records the information needed to write the values generated
+// by a Record as JSON objects. Where the Generator for a Field provides a
+// Value method the typed value is used so that numbers are written as JSON
+// numbers, times as RFC 3339 strings and Money values as decimals or
+// objects. Other Generators are written using the string returned by their
+// Generate method. Generators reporting that their value is NULL (see the
+// Nullable interface) are written as JSON null. The JSON object members are
+// written in the same order as the Fields in the Record.
+type JSONWriter struct {
+	moneyFmt MoneyJSONFormat
+	asArray  bool
+}
+
+// JSONWriterOptFunc is the type of an option-setting function that will set
+// a value in a JSONWriter
+type JSONWriterOptFunc func(jw *JSONWriter) error
+
+// JSONWriterSetMoneyFmt returns a JSONWriter Opt function which sets the way
+// that Money values are represented. The default is MoneyJSONDecimal.
+func JSONWriterSetMoneyFmt(mf MoneyJSONFormat) JSONWriterOptFunc {
+	return func(jw *JSONWriter) error {
+		if mf != MoneyJSONDecimal && mf != MoneyJSONObject {
+			return fmt.Errorf("bad MoneyJSONFormat: %d", mf)
+		}
+
+		jw.moneyFmt = mf
+
+		return nil
+	}
+}
+
+// JSONWriterSetArray returns a JSONWriter Opt function which sets whether
+// the rows are written as a single JSON array of objects or as JSON Lines
+// (one object per line). The default is to write JSON Lines.
+func JSONWriterSetArray(asArray bool) JSONWriterOptFunc {
+	return func(jw *JSONWriter) error {
+		jw.asArray = asArray
+		return nil
+	}
+}
+
+// NewJSONWriter creates a new JSONWriter object. By default it writes JSON
+// Lines with Money values given as decimal numbers. It will panic if any of
+// the option functions returns an error.
+func NewJSONWriter(opts ...JSONWriterOptFunc) *JSONWriter {
+	jw := &JSONWriter{}
+
+	for _, o := range opts {
+		if err := o(jw); err != nil {
+			panic(err)
+		}
+	}
+
+	return jw
+}
+
+// jsonValue returns the value to be marshalled to JSON for the Generator
+func (jw JSONWriter) jsonValue(g Generator) any {
+	if isNull(g) {
+		return nil
+	}
+
+	v, ok := typedValue(g)
+	if !ok {
+		return g.Generate()
+	}
+
+	switch tv := v.(type) {
+	case time.Time:
+		return tv.Format(time.RFC3339Nano)
+	case Money:
+		amt := json.Number(tv.decimalString())
+		if jw.moneyFmt == MoneyJSONObject {
+			return struct {
+				Amount   json.Number `json:"amount"`
+				Currency string      `json:"currency"`
+			}{
+				Amount:   amt,
+				Currency: tv.Ccy.code,
+			}
+		}
+
+		return amt
+	case json.Marshaler:
+		return tv
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		return v
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return g.Generate()
+		}
+
+		return v
+	}
+
+	return g.Generate()
+}
+
+// marshalJSON returns the JSON encoding of v. Unlike json.Marshal it does
+// not escape HTML characters so the generated values are written unchanged.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// RecordJSON returns the current values of the Record as a JSON object with
+// the members in the order of the Fields in the Record.
+func (jw JSONWriter) RecordJSON(r *Record) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, f := range r.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := marshalJSON(f.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := marshalJSON(jw.jsonValue(f.g))
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", f.Name(), err)
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// jsonRowWriter writes the rows of a Record as JSON objects. It implements
+// the RowWriter interface.
+type jsonRowWriter struct {
+	w       io.Writer
+	r       *Record
+	asArray bool
+	rowF    func() ([]byte, error)
+	count   int
+	last    bool
+}
+
+// WriteRow writes a JSON object generated from the current values of the
+// Record, preceded by the appropriate separator. Any error encountered
+// while writing, or reported by the Record, is returned.
+func (jrw *jsonRowWriter) WriteRow() error {
+	row, err := jrw.rowF()
+	if err != nil {
+		return err
+	}
+
+	if err := jrw.r.Err(); err != nil {
+		return err
+	}
+
+	sep := "\n"
+
+	switch {
+	case jrw.count == 0 && jrw.asArray:
+		sep = "[\n"
+	case jrw.count == 0:
+		sep = ""
+	case jrw.asArray:
+		sep = ",\n"
+	}
+
+	jrw.count++
+
+	if _, err := io.WriteString(jrw.w, sep); err != nil {
+		return err
+	}
+
+	_, err = jrw.w.Write(row)
+
+	return err
+}
+
+// Close writes the end of the JSON array or the final newline, unless this
+// is not the last part of the output
+func (jrw *jsonRowWriter) Close() error {
+	if !jrw.last {
+		return nil
+	}
+
+	end := ""
+
+	switch {
+	case jrw.count == 0 && jrw.asArray:
+		end = "[]\n"
+	case jrw.asArray:
+		end = "\n]\n"
+	case jrw.count > 0:
+		end = "\n"
+	}
+
+	_, err := io.WriteString(jrw.w, end)
+
+	return err
+}
+
+// NewRowWriter returns a RowWriter which will write the rows of the Record
+// to the io.Writer.
+func (jw JSONWriter) NewRowWriter(w io.Writer, r *Record) RowWriter {
+	return jw.NewPartRowWriter(w, r, 0, true)
+}
+
+// NewPartRowWriter returns a RowWriter which will write part of the rows of
+// the Record to the io.Writer (see RecordWriter). The start of the JSON
+// array is only written for the part starting at row 0 and the end only
+// for the last part.
+func (jw JSONWriter) NewPartRowWriter(w io.Writer, r *Record,
+	firstRow int, last bool,
+) RowWriter {
+	return &jsonRowWriter{
+		w:       w,
+		r:       r,
+		asArray: jw.asArray,
+		rowF:    func() ([]byte, error) { return jw.RecordJSON(r) },
+		count:   firstRow,
+		last:    last,
+	}
+}
+
+// Write writes the given number of rows generated by the Record to the
+// io.Writer. The first row is generated from the current values of the
+// Record which is moved on to its next value after each row (see Record).
+// Any error encountered while writing, or reported by the Record, is
+// returned.
+func (jw JSONWriter) Write(w io.Writer, r *Record, rows int) error {
+	return writeRows(jw.NewRowWriter(w, r), r, rows)
+}
@@ -0,0 +1,106 @@
+package datagen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// jsonTestRecord returns a Record with fields of each of the types that
+// the JSONWriter writes differently
+func jsonTestRecord() *datagen.Record {
+	usd := datagen.Countries["US"].Ccy()
+
+	return datagen.NewRecord("r",
+		datagen.NewField("id", incrGen(1, 1)),
+		datagen.NewField("ratio", datagen.NewGen(datagen.GenSetValue(0.5))),
+		datagen.NewField("when", datagen.NewTimeGen(
+			datagen.TimeGenSetInitialTime(
+				time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC)))),
+		datagen.NewField("price", datagen.NewGen(
+			datagen.GenSetValue(datagen.Money{Amt: -123, Ccy: usd}))),
+		datagen.NewField("note", datagen.NewGen(datagen.GenSetValue("<a & b>"))),
+		datagen.NewField("gone", datagen.NewNullGen(
+			datagen.NewConstGen[string]("x"), datagen.NewNullEveryNth(1, 0))),
+	)
+}
+
+func TestJSONWriter(t *testing.T) {
+	const (
+		obj1 = `{"id":1,"ratio":0.5,"when":"2024-03-01T09:30:00Z",` +
+			`"price":-1.23,"note":"<a & b>","gone":null}`
+		obj2 = `{"id":2,"ratio":0.5,"when":"2024-03-01T09:30:01Z",` +
+			`"price":-1.23,"note":"<a & b>","gone":null}`
+		objMoney = `{"id":1,"ratio":0.5,"when":"2024-03-01T09:30:00Z",` +
+			`"price":{"amount":-1.23,"currency":"USD"},` +
+			`"note":"<a & b>","gone":null}`
+	)
+
+	testCases := []struct {
+		name    string
+		jw      *datagen.JSONWriter
+		rows    int
+		expText string
+	}{
+		{
+			name:    "JSON Lines",
+			jw:      datagen.NewJSONWriter(),
+			rows:    2,
+			expText: obj1 + "\n" + obj2 + "\n",
+		},
+		{
+			name:    "array",
+			jw:      datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)),
+			rows:    2,
+			expText: "[\n" + obj1 + ",\n" + obj2 + "\n]\n",
+		},
+		{
+			name:    "empty array",
+			jw:      datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)),
+			rows:    0,
+			expText: "[]\n",
+		},
+		{
+			name: "money object",
+			jw: datagen.NewJSONWriter(
+				datagen.JSONWriterSetMoneyFmt(datagen.MoneyJSONObject)),
+			rows:    1,
+			expText: objMoney + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		if err := tc.jw.Write(&buf, jsonTestRecord(), tc.rows); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if buf.String() != tc.expText {
+			t.Errorf("%s: bad output\nexpected: %s\n     got: %s",
+				tc.name, tc.expText, buf.String())
+		}
+	}
+}
+
+func TestJSONWriterArrayIsValid(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)).
+		Write(&buf, jsonTestRecord(), 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rows []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("the output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if len(rows) != 5 {
+		t.Errorf("expected 5 rows, got %d", len(rows))
+	}
+}
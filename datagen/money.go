@@ -153,3 +153,22 @@ func (ccy Currency) MoneyMkStrFunc(nf *NumFmt) func(int64) string {
 		return s
 	}
 }
+
+// decimalString returns the money amount as a plain decimal number with the
+// number of decimal places given by the currency and no digit grouping or
+// currency symbol. For instance a Money value of -123 in US dollars would
+// give "-1.23".
+func (m Money) decimalString() string {
+	v := m.Amt
+
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v *= -1
+	}
+
+	decimalPart, v := stripDecimals(v, makeFactor[int64](m.Ccy.decimals),
+		m.Ccy.decimals, ".")
+
+	return fmt.Sprintf("%s%d%s", sign, v, decimalPart)
+}
@@ -0,0 +1,334 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// cholTolerance is the relative size below which a pivot in the Cholesky
+// decomposition is taken to be zero. This allows perfectly correlated
+// components.
+const cholTolerance = 1e-12
+
+// symTolerance is the relative difference allowed between the elements of
+// a matrix which should be equal for it to be symmetric
+const symTolerance = 1e-9
+
+// MultiNormalGen draws vectors of values from a multivariate normal
+// distribution so that the components are correlated. Each component is
+// made available as its own TypedGenerator (see Component) so that they
+// can be used in separate Fields of a Record.
+//
+// A new vector is drawn when a component's value is needed after it has
+// been moved on. The components should therefore all be moved on together,
+// as they are when they are Fields of the same Record.
+type MultiNormalGen struct {
+	r     *rand.Rand
+	means []float64
+	chol  [][]float64
+
+	vals  []float64
+	z     []float64
+	count int
+}
+
+// MultiNormalGenOptFunc is the type of an option-setting function that
+// will set a value in a MultiNormalGen
+type MultiNormalGenOptFunc func(mn *MultiNormalGen) error
+
+// MultiNormalGenSetSeeder returns a MultiNormalGen Opt function which sets
+// the random number generator to one taken from the supplied Seeder. This
+// allows the generated values to be reproduced.
+func MultiNormalGenSetSeeder(s *Seeder) MultiNormalGenOptFunc {
+	return func(mn *MultiNormalGen) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		mn.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// NewMultiNormalGen creates a new MultiNormalGen with the given means and
+// covariance matrix. It will panic if there are no means, if the
+// covariance matrix is not a square, symmetric matrix with a row for each
+// mean, if it is not positive semi-definite or if any of the option
+// functions returns an error.
+func NewMultiNormalGen(means []float64, cov [][]float64,
+	opts ...MultiNormalGenOptFunc,
+) *MultiNormalGen {
+	if len(means) == 0 {
+		panic(errors.New("no means have been given"))
+	}
+
+	chol, err := cholesky(cov, len(means))
+	if err != nil {
+		panic(err)
+	}
+
+	mn := &MultiNormalGen{
+		means: append([]float64(nil), means...),
+		chol:  chol,
+		vals:  make([]float64, len(means)),
+		z:     make([]float64, len(means)),
+	}
+
+	for _, o := range opts {
+		if err := o(mn); err != nil {
+			panic(err)
+		}
+	}
+
+	if mn.r == nil {
+		mn.r = NewRand()
+	}
+
+	mn.draw()
+
+	return mn
+}
+
+// NewMultiNormalGenCorr creates a new MultiNormalGen with the given means,
+// standard deviations and correlation matrix. It will panic if the numbers
+// of means and standard deviations differ, if any standard deviation is <
+// 0, if any correlation is not between -1 and 1 or for any of the reasons
+// given for NewMultiNormalGen.
+func NewMultiNormalGenCorr(means, sds []float64, corr [][]float64,
+	opts ...MultiNormalGenOptFunc,
+) *MultiNormalGen {
+	if len(sds) != len(means) {
+		panic(fmt.Errorf("there are %d means but %d standard deviations",
+			len(means), len(sds)))
+	}
+
+	for i, sd := range sds {
+		if !(sd >= 0) {
+			panic(fmt.Errorf("the standard deviation[%d] (%g) must be >= 0",
+				i, sd))
+		}
+	}
+
+	if err := checkMatrix(corr, len(means)); err != nil {
+		panic(err)
+	}
+
+	cov := make([][]float64, len(means))
+	for i, row := range corr {
+		cov[i] = make([]float64, len(row))
+
+		for j, c := range row {
+			if !(c >= -1 && c <= 1) {
+				panic(fmt.Errorf(
+					"the correlation[%d][%d] (%g) must be between -1 and 1",
+					i, j, c))
+			}
+
+			cov[i][j] = c * (sds[i] * sds[j])
+		}
+	}
+
+	return NewMultiNormalGen(means, cov, opts...)
+}
+
+// checkMatrix returns an error if the matrix is not square and symmetric
+// (to within a small tolerance) with n rows
+func checkMatrix(m [][]float64, n int) error {
+	if len(m) != n {
+		return fmt.Errorf("the matrix has %d rows, it should have %d",
+			len(m), n)
+	}
+
+	for i, row := range m {
+		if len(row) != n {
+			return fmt.Errorf("row %d of the matrix has %d columns,"+
+				" it should have %d",
+				i, len(row), n)
+		}
+	}
+
+	for i := range n {
+		for j := range i {
+			diff := math.Abs(m[i][j] - m[j][i])
+			size := math.Max(math.Abs(m[i][j]), math.Abs(m[j][i]))
+
+			if diff > symTolerance*size {
+				return fmt.Errorf("the matrix is not symmetric:"+
+					" [%d][%d] (%g) != [%d][%d] (%g)",
+					i, j, m[i][j], j, i, m[j][i])
+			}
+		}
+	}
+
+	return nil
+}
+
+// cholesky checks the covariance matrix and returns its Cholesky
+// decomposition, the lower triangular matrix L such that L.L' = cov.
+func cholesky(cov [][]float64, n int) ([][]float64, error) {
+	if err := checkMatrix(cov, n); err != nil {
+		return nil, err
+	}
+
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, i+1)
+	}
+
+	for j := range n {
+		d := cov[j][j]
+		for k := range j {
+			d -= l[j][k] * l[j][k]
+		}
+
+		if d < -cholTolerance*math.Max(1, cov[j][j]) {
+			return nil, errors.New("the matrix is not positive semi-definite")
+		}
+
+		if d <= cholTolerance*math.Max(1, cov[j][j]) {
+			// this component is fully determined by the earlier ones so
+			// what remains of its covariance with the later ones must
+			// also be zero
+			if err := checkZeroCol(cov, l, j); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		l[j][j] = math.Sqrt(d)
+
+		for i := j + 1; i < n; i++ {
+			s := cov[i][j]
+			for k := range j {
+				s -= l[i][k] * l[j][k]
+			}
+
+			l[i][j] = s / l[j][j]
+		}
+	}
+
+	return l, nil
+}
+
+// checkZeroCol returns an error if, for a component j with a zero pivot,
+// any of the covariances with the later components is not accounted for
+// by the earlier components. Such a matrix is not positive semi-definite.
+func checkZeroCol(cov, l [][]float64, j int) error {
+	for i := j + 1; i < len(cov); i++ {
+		s := cov[i][j]
+		for k := range j {
+			s -= l[i][k] * l[j][k]
+		}
+
+		scale := math.Sqrt(math.Abs(cov[i][i] * cov[j][j]))
+		if math.Abs(s) > cholTolerance*math.Max(1, scale) {
+			return errors.New("the matrix is not positive semi-definite")
+		}
+	}
+
+	return nil
+}
+
+// draw sets the values to a new vector from the distribution
+func (mn *MultiNormalGen) draw() {
+	for i := range mn.z {
+		mn.z[i] = mn.r.NormFloat64()
+	}
+
+	for i, row := range mn.chol {
+		v := mn.means[i]
+		for k, c := range row {
+			v += c * mn.z[k]
+		}
+
+		mn.vals[i] = v
+	}
+}
+
+// Len returns the number of components
+func (mn MultiNormalGen) Len() int {
+	return len(mn.means)
+}
+
+// Component returns a TypedGenerator giving the values of the i'th
+// component. It will panic if i is out of range or if any of the option
+// functions returns an error.
+func (mn *MultiNormalGen) Component(i int,
+	opts ...MultiNormalComponentOptFunc,
+) *MultiNormalComponent {
+	if i < 0 || i >= mn.Len() {
+		panic(fmt.Errorf("the component index (%d) must be between 0 and %d",
+			i, mn.Len()-1))
+	}
+
+	c := &MultiNormalComponent{
+		mn:    mn,
+		idx:   i,
+		count: mn.count,
+		sm:    dfltGenImpl[float64]{},
+	}
+
+	for _, o := range opts {
+		if err := o(c); err != nil {
+			panic(err)
+		}
+	}
+
+	return c
+}
+
+// MultiNormalComponent gives the values of one component of the vectors
+// drawn by a MultiNormalGen. It implements the TypedGenerator interface.
+type MultiNormalComponent struct {
+	mn    *MultiNormalGen
+	idx   int
+	count int
+	sm    StringMaker[float64]
+}
+
+// MultiNormalComponentOptFunc is the type of an option-setting function
+// that will set a value in a MultiNormalComponent
+type MultiNormalComponentOptFunc func(c *MultiNormalComponent) error
+
+// MultiNormalComponentSetStringMaker returns a MultiNormalComponent Opt
+// function which sets the StringMaker used to generate the string form of
+// the value. The default gives the Go string representation of the value.
+func MultiNormalComponentSetStringMaker(
+	sm StringMaker[float64],
+) MultiNormalComponentOptFunc {
+	return func(c *MultiNormalComponent) error {
+		if sm == nil {
+			return errors.New("a nil string maker has been supplied")
+		}
+
+		c.sm = sm
+
+		return nil
+	}
+}
+
+// Value returns the value of the component, drawing a new vector if this
+// component has been moved on past the current vector
+func (c *MultiNormalComponent) Value() float64 {
+	if c.count != c.mn.count {
+		c.mn.draw()
+		c.mn.count = c.count
+	}
+
+	return c.mn.vals[c.idx]
+}
+
+// Generate returns the string form of the value of the component
+func (c *MultiNormalComponent) Generate() string {
+	return c.sm.MakeString(c.Value())
+}
+
+// Next moves the component on so that a new vector will be drawn when the
+// value is next needed
+func (c *MultiNormalComponent) Next() {
+	c.count++
+}
@@ -0,0 +1,106 @@
+package datagen_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+func TestMultiNormalGenCorrelation(t *testing.T) {
+	const draws = 100000
+
+	mn := datagen.NewMultiNormalGenCorr(
+		[]float64{10, -5, 0},
+		[]float64{2, 3, 1},
+		[][]float64{{1, 0.8, 1}, {0.8, 1, 0.8}, {1, 0.8, 1}},
+		datagen.MultiNormalGenSetSeeder(datagen.NewSeeder(42)))
+	a, b, c := mn.Component(0), mn.Component(1), mn.Component(2)
+
+	var sumA, sumB, sumAA, sumBB, sumAB float64
+
+	for range draws {
+		va, vb := a.Value(), b.Value()
+
+		// the third component is perfectly correlated with the first
+		if vc := c.Value(); math.Abs(vc-(va-10)/2) > 1e-9 {
+			t.Fatalf("expected %g to be (%g-10)/2", vc, va)
+		}
+
+		sumA += va
+		sumB += vb
+		sumAA += va * va
+		sumBB += vb * vb
+		sumAB += va * vb
+
+		a.Next()
+		b.Next()
+		c.Next()
+	}
+
+	meanA, meanB := sumA/draws, sumB/draws
+	sdA := math.Sqrt(sumAA/draws - meanA*meanA)
+	sdB := math.Sqrt(sumBB/draws - meanB*meanB)
+	corr := (sumAB/draws - meanA*meanB) / (sdA * sdB)
+
+	if math.Abs(meanA-10) > 0.05 || math.Abs(meanB+5) > 0.05 {
+		t.Errorf("expected means of about 10 and -5, got %g and %g",
+			meanA, meanB)
+	}
+
+	if math.Abs(sdA-2) > 0.05 || math.Abs(sdB-3) > 0.05 {
+		t.Errorf("expected standard deviations of about 2 and 3,"+
+			" got %g and %g", sdA, sdB)
+	}
+
+	if math.Abs(corr-0.8) > 0.01 {
+		t.Errorf("expected a correlation of about 0.8, got %g", corr)
+	}
+}
+
+func TestMultiNormalGenBadMatrix(t *testing.T) {
+	testCases := []struct {
+		name   string
+		corr   [][]float64
+		expErr string
+	}{
+		{
+			name:   "not square",
+			corr:   [][]float64{{1, 0}, {0}},
+			expErr: "row 1 of the matrix has 1 columns",
+		},
+		{
+			name:   "not symmetric",
+			corr:   [][]float64{{1, 0.5}, {0.4, 1}},
+			expErr: "not symmetric",
+		},
+		{
+			name:   "negative pivot",
+			corr:   [][]float64{{1, 0.9, 0.9}, {0.9, 1, -0.9}, {0.9, -0.9, 1}},
+			expErr: "not positive semi-definite",
+		},
+		{
+			name:   "zero pivot with a remaining covariance",
+			corr:   [][]float64{{1, 1, 0}, {1, 1, 0.5}, {0, 0.5, 1}},
+			expErr: "not positive semi-definite",
+		},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				p := recover()
+				err, ok := p.(error)
+
+				if !ok || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("%s: a panic containing %q was expected, got: %v",
+						tc.name, tc.expErr, p)
+				}
+			}()
+
+			datagen.NewMultiNormalGenCorr(make([]float64, len(tc.corr)),
+				[]float64{1, 1, 1}[:len(tc.corr)], tc.corr)
+		}()
+	}
+}
@@ -0,0 +1,350 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// NullPolicy is the interface describing the methods that a policy for
+// choosing when values should be missing (NULL) must provide. IsNull
+// reports whether the current value is NULL and Next moves the policy on to
+// its next value.
+type NullPolicy interface {
+	Nullable
+	Next()
+}
+
+// ===================================================================
+
+// NullGen wraps a Generator and replaces its generated value with a null
+// token when the NullPolicy reports that the value is NULL. It implements
+// the Generator and Nullable interfaces so the CSVWriter will write the
+// null token and the JSONWriter and SQLWriter will write a NULL value.
+type NullGen struct {
+	g       Generator
+	policy  NullPolicy
+	nullStr string
+}
+
+// NullGenOptFunc is the type of an option-setting function that will set a
+// value in a NullGen
+type NullGenOptFunc func(ng *NullGen) error
+
+// NullGenSetNullStr returns a NullGen Opt function which sets the string
+// generated when the value is NULL. Typical values are "", "NULL" or `\N`.
+// The default is the empty string.
+func NullGenSetNullStr(s string) NullGenOptFunc {
+	return func(ng *NullGen) error {
+		ng.nullStr = s
+		return nil
+	}
+}
+
+// initNullGen sets the values in the NullGen and applies the options. It
+// will panic if the Generator or the NullPolicy is nil or if any of the
+// option functions returns an error.
+func initNullGen(ng *NullGen,
+	g Generator, p NullPolicy, opts ...NullGenOptFunc,
+) {
+	if g == nil {
+		panic(errors.New("a nil Generator has been supplied"))
+	}
+
+	if p == nil {
+		panic(errors.New("a nil NullPolicy has been supplied"))
+	}
+
+	ng.g = g
+	ng.policy = p
+
+	for _, o := range opts {
+		if err := o(ng); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// NewNullGen creates a new NullGen wrapping the Generator. It will panic if
+// the Generator or the NullPolicy is nil or if any of the option functions
+// returns an error.
+func NewNullGen(g Generator, p NullPolicy, opts ...NullGenOptFunc) *NullGen {
+	ng := &NullGen{}
+	initNullGen(ng, g, p, opts...)
+
+	return ng
+}
+
+// Generate returns the null string if the value is NULL and the value
+// generated by the wrapped Generator otherwise.
+func (ng NullGen) Generate() string {
+	if ng.policy.IsNull() {
+		return ng.nullStr
+	}
+
+	return ng.g.Generate()
+}
+
+// Next moves the wrapped Generator and the NullPolicy on to their next
+// values
+func (ng *NullGen) Next() {
+	ng.g.Next()
+	ng.policy.Next()
+}
+
+// IsNull returns true if the current value is NULL
+func (ng NullGen) IsNull() bool {
+	return ng.policy.IsNull()
+}
+
+// Err returns any error reported by the wrapped Generator
+func (ng NullGen) Err() error {
+	if er, ok := ng.g.(ErrReporter); ok {
+		return er.Err()
+	}
+
+	return nil
+}
+
+// TypedNullGen wraps a TypedGenerator in the same way as a NullGen wraps a
+// Generator. It implements the TypedGenerator interface.
+type TypedNullGen[T any] struct {
+	NullGen
+	tg TypedGenerator[T]
+}
+
+// NewTypedNullGen creates a new TypedNullGen wrapping the TypedGenerator. It
+// will panic if the TypedGenerator or the NullPolicy is nil or if any of the
+// option functions returns an error.
+func NewTypedNullGen[T any](
+	tg TypedGenerator[T], p NullPolicy, opts ...NullGenOptFunc,
+) *TypedNullGen[T] {
+	tng := &TypedNullGen[T]{tg: tg}
+	initNullGen(&tng.NullGen, tg, p, opts...)
+
+	return tng
+}
+
+// Value returns the zero value of the type if the value is NULL and the
+// value of the wrapped TypedGenerator otherwise.
+func (tng TypedNullGen[T]) Value() T {
+	if tng.policy.IsNull() {
+		var zero T
+		return zero
+	}
+
+	return tng.tg.Value()
+}
+
+// ===================================================================
+
+// NullProb is a NullPolicy which makes values NULL at random with the
+// given probability.
+type NullProb struct {
+	p      float64
+	r      *rand.Rand
+	isNull bool
+}
+
+// NullProbOptFunc is the type of an option-setting function that will set a
+// value in a NullProb
+type NullProbOptFunc func(np *NullProb) error
+
+// NullProbSetSeeder returns a NullProb Opt function which sets the random
+// number generator to one taken from the supplied Seeder.
+func NullProbSetSeeder(s *Seeder) NullProbOptFunc {
+	return func(np *NullProb) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		np.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// checkProb returns an error if the probability is not between 0 and 1
+func checkProb(p float64) error {
+	if p < 0 || p > 1 {
+		return fmt.Errorf("the probability (%g) must be between 0 and 1", p)
+	}
+
+	return nil
+}
+
+// NewNullProb creates a new NullProb which will make values NULL with
+// probability p. It will panic if p is not between 0 and 1 or if any of the
+// option functions returns an error.
+func NewNullProb(p float64, opts ...NullProbOptFunc) *NullProb {
+	if err := checkProb(p); err != nil {
+		panic(err)
+	}
+
+	np := &NullProb{p: p}
+
+	for _, o := range opts {
+		if err := o(np); err != nil {
+			panic(err)
+		}
+	}
+
+	if np.r == nil {
+		np.r = NewRand()
+	}
+
+	np.Next()
+
+	return np
+}
+
+// IsNull returns true if the current value is NULL
+func (np NullProb) IsNull() bool {
+	return np.isNull
+}
+
+// Next decides whether the next value is NULL
+func (np *NullProb) Next() {
+	np.isNull = np.r.Float64() < np.p
+}
+
+// ===================================================================
+
+// NullEveryNth is a NullPolicy which makes every n'th value NULL.
+type NullEveryNth struct {
+	n      int
+	offset int
+	count  int
+}
+
+// NewNullEveryNth creates a new NullEveryNth which will make every n'th
+// value NULL, starting with the value at the given offset (counting from
+// 0). It will panic if n is <= 0 or the offset is not between 0 and n-1.
+func NewNullEveryNth(n, offset int) *NullEveryNth {
+	if n <= 0 {
+		panic(fmt.Errorf("the null interval (%d) must be > 0", n))
+	}
+
+	if offset < 0 || offset >= n {
+		panic(fmt.Errorf("the offset (%d) must be between 0 and %d",
+			offset, n-1))
+	}
+
+	return &NullEveryNth{n: n, offset: offset}
+}
+
+// IsNull returns true if the current value is NULL
+func (ne NullEveryNth) IsNull() bool {
+	return ne.count == ne.offset
+}
+
+// Next moves the count on to the next value
+func (ne *NullEveryNth) Next() {
+	ne.count++
+	if ne.count >= ne.n {
+		ne.count = 0
+	}
+}
+
+// ===================================================================
+
+// NullRuns is a NullPolicy which starts runs of NULL values at random. A
+// run starts with probability p and continues for runLen values.
+type NullRuns struct {
+	p         float64
+	runLen    int
+	r         *rand.Rand
+	remaining int
+}
+
+// NullRunsOptFunc is the type of an option-setting function that will set a
+// value in a NullRuns
+type NullRunsOptFunc func(nr *NullRuns) error
+
+// NullRunsSetSeeder returns a NullRuns Opt function which sets the random
+// number generator to one taken from the supplied Seeder.
+func NullRunsSetSeeder(s *Seeder) NullRunsOptFunc {
+	return func(nr *NullRuns) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		nr.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// NewNullRuns creates a new NullRuns which will start a run of runLen NULL
+// values with probability p. It will panic if p is not between 0 and 1, if
+// runLen is <= 0 or if any of the option functions returns an error.
+func NewNullRuns(p float64, runLen int, opts ...NullRunsOptFunc) *NullRuns {
+	if err := checkProb(p); err != nil {
+		panic(err)
+	}
+
+	if runLen <= 0 {
+		panic(fmt.Errorf("the run length (%d) must be > 0", runLen))
+	}
+
+	nr := &NullRuns{p: p, runLen: runLen}
+
+	for _, o := range opts {
+		if err := o(nr); err != nil {
+			panic(err)
+		}
+	}
+
+	if nr.r == nil {
+		nr.r = NewRand()
+	}
+
+	nr.Next()
+
+	return nr
+}
+
+// IsNull returns true if the current value is NULL
+func (nr NullRuns) IsNull() bool {
+	return nr.remaining > 0
+}
+
+// Next decides whether the next value is NULL, either continuing the
+// current run or possibly starting a new one.
+func (nr *NullRuns) Next() {
+	if nr.remaining > 0 {
+		nr.remaining--
+	}
+
+	if nr.remaining == 0 && nr.r.Float64() < nr.p {
+		nr.remaining = nr.runLen
+	}
+}
+
+// ===================================================================
+
+// NullWhen is a NullPolicy which makes the value NULL when the value check
+// passes. The check is evaluated each time IsNull is called so it can
+// depend on the current values of other fields.
+type NullWhen struct {
+	vCk *ValCk
+}
+
+// NewNullWhen creates a new NullWhen which will make the value NULL when the
+// value check passes. It will panic if the value check is nil.
+func NewNullWhen(vCk *ValCk) *NullWhen {
+	if vCk == nil {
+		panic(errors.New("a nil value check has been supplied"))
+	}
+
+	return &NullWhen{vCk: vCk}
+}
+
+// IsNull returns true if the value check passes
+func (nw NullWhen) IsNull() bool {
+	return nw.vCk.Passes()
+}
+
+// Next does nothing; the check is evaluated by IsNull
+func (nw NullWhen) Next() {
+}
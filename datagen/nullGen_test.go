@@ -0,0 +1,159 @@
+package datagen_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// nullStrs returns the strings generated by the Generator over n rows
+func nullStrs(g datagen.Generator, n int) []string {
+	var s []string
+
+	for range n {
+		s = append(s, g.Generate())
+		g.Next()
+	}
+
+	return s
+}
+
+func TestNullGenEveryNth(t *testing.T) {
+	ng := datagen.NewNullGen(incrGen(0, 1), datagen.NewNullEveryNth(3, 1),
+		datagen.NullGenSetNullStr("NULL"))
+
+	got := nullStrs(ng, 7)
+	exp := []string{"0", "NULL", "2", "3", "NULL", "5", "6"}
+
+	if !slices.Equal(got, exp) {
+		t.Errorf("bad values\nexpected: %v\n     got: %v", exp, got)
+	}
+}
+
+func TestTypedNullGen(t *testing.T) {
+	tng := datagen.NewTypedNullGen[int](incrGen(5, 1),
+		datagen.NewNullEveryNth(2, 0))
+
+	var got []int
+
+	for range 4 {
+		got = append(got, tng.Value())
+		tng.Next()
+	}
+
+	if exp := []int{0, 6, 0, 8}; !slices.Equal(got, exp) {
+		t.Errorf("bad values\nexpected: %v\n     got: %v", exp, got)
+	}
+}
+
+func TestNullProb(t *testing.T) {
+	const rows = 10000
+
+	np := datagen.NewNullProb(0.25,
+		datagen.NullProbSetSeeder(datagen.NewSeeder(42)))
+	nulls := 0
+
+	for range rows {
+		if np.IsNull() {
+			nulls++
+		}
+
+		np.Next()
+	}
+
+	if nulls < 2300 || nulls > 2700 {
+		t.Errorf("expected about 2500 NULLs in %d rows, got %d", rows, nulls)
+	}
+
+	for _, p := range []float64{0, 1} {
+		np := datagen.NewNullProb(p)
+		for range 100 {
+			if np.IsNull() != (p == 1) {
+				t.Errorf("probability %g: bad IsNull: %t", p, np.IsNull())
+				break
+			}
+
+			np.Next()
+		}
+	}
+}
+
+func TestNullRuns(t *testing.T) {
+	const runLen = 4
+
+	nr := datagen.NewNullRuns(0.1, runLen,
+		datagen.NullRunsSetSeeder(datagen.NewSeeder(42)))
+	run := 0
+	runs := 0
+
+	for range 10000 {
+		if nr.IsNull() {
+			run++
+		} else if run > 0 {
+			if run%runLen != 0 {
+				t.Errorf("a run of %d NULLs is not a multiple of %d",
+					run, runLen)
+			}
+
+			runs++
+			run = 0
+		}
+
+		nr.Next()
+	}
+
+	if runs == 0 {
+		t.Error("no runs of NULLs were generated")
+	}
+}
+
+func TestNullWhen(t *testing.T) {
+	v := incrGen(0, 1)
+	nw := datagen.NewNullWhen(datagen.NewValCk(
+		func(i int) error {
+			if i%2 != 0 {
+				return errors.New("odd")
+			}
+
+			return nil
+		}, v))
+	ng := datagen.NewNullGen(v, nw)
+
+	got := nullStrs(ng, 4)
+	if exp := []string{"", "1", "", "3"}; !slices.Equal(got, exp) {
+		t.Errorf("bad values\nexpected: %v\n     got: %v", exp, got)
+	}
+}
+
+func TestNullPolicyBadArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{name: "nil generator", f: func() {
+			datagen.NewNullGen(nil, datagen.NewNullEveryNth(1, 0))
+		}},
+		{name: "nil policy", f: func() {
+			datagen.NewNullGen(incrGen(0, 1), nil)
+		}},
+		{name: "probability > 1", f: func() { datagen.NewNullProb(1.5) }},
+		{name: "n == 0", f: func() { datagen.NewNullEveryNth(0, 0) }},
+		{name: "offset >= n", f: func() { datagen.NewNullEveryNth(2, 2) }},
+		{name: "run length 0", f: func() { datagen.NewNullRuns(0.5, 0) }},
+		{name: "nil check", f: func() { datagen.NewNullWhen(nil) }},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: a panic was expected", tc.name)
+				}
+			}()
+
+			tc.f()
+		}()
+	}
+}
@@ -0,0 +1,204 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"golang.org/x/exp/constraints"
+)
+
+// ProcessValSetter implements a ValSetter that will move the passed value
+// on by one step of a stochastic process, such as a random walk, so that
+// successive values form a realistic series (prices, temperatures, queue
+// lengths and so on). Use one of the constructors (NewRandomWalkValSetter,
+// NewOUValSetter or NewGBMValSetter) to create it.
+//
+// The state of the process is held as a float64 so that small steps are not
+// lost when the values are of an integer type; the value given is the
+// state truncated to the type. The state is taken from the passed value on
+// the first call and whenever the value has been changed by something
+// else. It can be used for a Money amount by wrapping a ValSetter[int64] in
+// a MoneyValSetter.
+type ProcessValSetter[T constraints.Integer | constraints.Float] struct {
+	r    *rand.Rand
+	step func(x float64) float64
+
+	bounded  bool
+	min, max float64
+
+	x       float64
+	last    T
+	started bool
+}
+
+// ProcessValSetterOptFunc is the type of an option-setting function that
+// will set a value in a ProcessValSetter
+type ProcessValSetterOptFunc[T constraints.Integer | constraints.Float] func(
+	vs *ProcessValSetter[T],
+) error
+
+// ProcessValSetterSetSeeder returns a ProcessValSetter Opt function which
+// sets the random number generator to one taken from the supplied Seeder.
+// This allows the generated values to be reproduced.
+func ProcessValSetterSetSeeder[T constraints.Integer | constraints.Float](
+	s *Seeder,
+) ProcessValSetterOptFunc[T] {
+	return func(vs *ProcessValSetter[T]) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		vs.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// ProcessValSetterSetBounds returns a ProcessValSetter Opt function which
+// constrains the values to lie between the minimum and maximum values. A
+// step which would take the value beyond a bound is reflected back from it.
+func ProcessValSetterSetBounds[T constraints.Integer | constraints.Float](
+	minimum, maximum T,
+) ProcessValSetterOptFunc[T] {
+	return func(vs *ProcessValSetter[T]) error {
+		if minimum >= maximum {
+			return fmt.Errorf("the minimum (%v) must be < the maximum (%v)",
+				minimum, maximum)
+		}
+
+		vs.bounded = true
+		vs.min = float64(minimum)
+		vs.max = float64(maximum)
+
+		return nil
+	}
+}
+
+// newProcessValSetter creates a ProcessValSetter with the given step
+// function and applies the options. It will panic if any of the option
+// functions returns an error.
+func newProcessValSetter[T constraints.Integer | constraints.Float](
+	step func(r *rand.Rand, x float64) float64,
+	opts ...ProcessValSetterOptFunc[T],
+) *ProcessValSetter[T] {
+	vs := &ProcessValSetter[T]{}
+
+	for _, o := range opts {
+		if err := o(vs); err != nil {
+			panic(err)
+		}
+	}
+
+	if vs.r == nil {
+		vs.r = NewRand()
+	}
+
+	vs.step = func(x float64) float64 { return step(vs.r, x) }
+
+	return vs
+}
+
+// reflect returns the value reflected back within the bounds
+func (vs ProcessValSetter[T]) reflect(x float64) float64 {
+	if !vs.bounded {
+		return x
+	}
+
+	if x > vs.max {
+		x = 2*vs.max - x
+	}
+
+	if x < vs.min {
+		x = 2*vs.min - x
+	}
+
+	return math.Max(vs.min, math.Min(vs.max, x))
+}
+
+// SetVal moves the given value on by one step of the process
+func (vs *ProcessValSetter[T]) SetVal(v *T) {
+	if !vs.started || *v != vs.last {
+		vs.x = vs.reflect(float64(*v))
+		vs.started = true
+	}
+
+	vs.x = vs.reflect(vs.step(vs.x))
+	vs.last = T(vs.x)
+	*v = vs.last
+}
+
+// NewRandomWalkValSetter creates and returns a ProcessValSetter giving a
+// random walk; each step adds the drift and a normally distributed amount
+// with the given standard deviation. Use ProcessValSetterSetBounds to give
+// a bounded walk. It will panic if the standard deviation is < 0 or if any
+// of the option functions returns an error.
+func NewRandomWalkValSetter[T constraints.Integer | constraints.Float](
+	drift, sd float64,
+	opts ...ProcessValSetterOptFunc[T],
+) *ProcessValSetter[T] {
+	if !(sd >= 0) {
+		panic(fmt.Errorf("the random walk standard deviation (%g)"+
+			" must be >= 0", sd))
+	}
+
+	return newProcessValSetter(func(r *rand.Rand, x float64) float64 {
+		return x + drift + sd*r.NormFloat64()
+	}, opts...)
+}
+
+// NewOUValSetter creates and returns a ProcessValSetter giving an
+// Ornstein-Uhlenbeck (mean-reverting) process. The value is pulled towards
+// the mean at the given rate per step while being moved by noise with the
+// given volatility per step. Over many steps the values are normally
+// distributed about the mean with a standard deviation of
+// volatility/sqrt(2*rate). The process is sampled exactly at each step so
+// any rate > 0 may be used. It will panic if the rate is not > 0, if the
+// volatility is < 0 or if any of the option functions returns an error.
+func NewOUValSetter[T constraints.Integer | constraints.Float](
+	mean, rate, volatility float64,
+	opts ...ProcessValSetterOptFunc[T],
+) *ProcessValSetter[T] {
+	if !(rate > 0) {
+		panic(fmt.Errorf("the mean reversion rate (%g) must be > 0", rate))
+	}
+
+	if !(volatility >= 0) {
+		panic(fmt.Errorf("the volatility (%g) must be >= 0", volatility))
+	}
+
+	decay := math.Exp(-rate)
+	noise := volatility * math.Sqrt((1-decay*decay)/(2*rate)) //nolint:mnd
+
+	return newProcessValSetter(func(r *rand.Rand, x float64) float64 {
+		return mean + (x-mean)*decay + noise*r.NormFloat64()
+	}, opts...)
+}
+
+// NewGBMValSetter creates and returns a ProcessValSetter giving geometric
+// Brownian motion, as commonly used for prices. At each step the value is
+// multiplied by a log-normally distributed factor so that the expected
+// proportional change per step is the drift and the standard deviation of
+// the log of the factor is the volatility. The value never changes sign
+// and a zero value stays at zero. It will panic if the drift is not > -1,
+// if the volatility is < 0 or if any of the option functions returns an
+// error.
+func NewGBMValSetter[T constraints.Integer | constraints.Float](
+	drift, volatility float64,
+	opts ...ProcessValSetterOptFunc[T],
+) *ProcessValSetter[T] {
+	if !(drift > -1) {
+		panic(fmt.Errorf("the drift (%g) must be > -1", drift))
+	}
+
+	if !(volatility >= 0) {
+		panic(fmt.Errorf("the volatility (%g) must be >= 0", volatility))
+	}
+
+	mu := math.Log1p(drift) - volatility*volatility/2 //nolint:mnd
+
+	return newProcessValSetter(func(r *rand.Rand, x float64) float64 {
+		return x * math.Exp(mu+volatility*r.NormFloat64())
+	}, opts...)
+}
@@ -0,0 +1,146 @@
+package datagen_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// procSeed returns the seed option used by the process tests
+func procSeed[T int | float64]() datagen.ProcessValSetterOptFunc[T] {
+	return datagen.ProcessValSetterSetSeeder[T](datagen.NewSeeder(42))
+}
+
+// steps returns the values after each of n steps from the initial value
+func steps[T int | float64](vs datagen.ValSetter[T], v T, n int) []T {
+	var got []T
+
+	for range n {
+		vs.SetVal(&v)
+		got = append(got, v)
+	}
+
+	return got
+}
+
+func TestRandomWalkValSetter(t *testing.T) {
+	// small steps accumulate even though the values are ints
+	got := steps[int](datagen.NewRandomWalkValSetter[int](0.4, 0), 0, 6)
+	if exp := []int{0, 0, 1, 1, 2, 2}; !slices.Equal(got, exp) {
+		t.Errorf("bad walk\nexpected: %v\n     got: %v", exp, got)
+	}
+
+	// the state is restarted from a value changed by something else
+	vs := datagen.NewRandomWalkValSetter[int](1, 0)
+	v := 0
+	vs.SetVal(&v)
+	v = 10
+	vs.SetVal(&v)
+
+	if v != 11 {
+		t.Errorf("expected the walk to carry on from 10, got %d", v)
+	}
+
+	bounded := steps[float64](datagen.NewRandomWalkValSetter(0, 5,
+		procSeed[float64](),
+		datagen.ProcessValSetterSetBounds[float64](-10, 10)), 0, 10000)
+	if lo, hi := slices.Min(bounded), slices.Max(bounded); lo < -10 || hi > 10 {
+		t.Errorf("the bounded walk went from %g to %g, outside [-10, 10]",
+			lo, hi)
+	}
+}
+
+func TestOUValSetter(t *testing.T) {
+	const (
+		mean = 20.0
+		rate = 0.5
+		vol  = 2.0
+	)
+
+	vals := steps[float64](datagen.NewOUValSetter(mean, rate, vol,
+		procSeed[float64]()), 100, 100000)
+
+	// skip the steps while the value reverts from its start
+	vals = vals[100:]
+
+	tot, totSq := 0.0, 0.0
+	for _, v := range vals {
+		tot += v
+		totSq += v * v
+	}
+
+	n := float64(len(vals))
+	gotMean := tot / n
+	gotSD := math.Sqrt(totSq/n - gotMean*gotMean)
+	expSD := vol / math.Sqrt(2*rate)
+
+	if math.Abs(gotMean-mean) > 0.1 {
+		t.Errorf("expected a mean of about %g, got %g", mean, gotMean)
+	}
+
+	if math.Abs(gotSD-expSD) > 0.1 {
+		t.Errorf("expected a standard deviation of about %g, got %g",
+			expSD, gotSD)
+	}
+}
+
+func TestGBMValSetter(t *testing.T) {
+	const draws = 100000
+
+	vs := datagen.NewGBMValSetter(0.01, 0.2, procSeed[float64]())
+	tot := 0.0
+
+	for range draws {
+		v := 100.0
+		vs.SetVal(&v)
+
+		if v <= 0 {
+			t.Fatalf("the value changed sign: %g", v)
+		}
+
+		tot += v
+	}
+
+	if mean := tot / draws; math.Abs(mean-101) > 0.3 {
+		t.Errorf("expected a mean step from 100 to about 101, got %g", mean)
+	}
+
+	if got := steps[float64](vs, 0, 3); !slices.Equal(got, []float64{0, 0, 0}) {
+		t.Errorf("a zero value should stay at zero, got %v", got)
+	}
+}
+
+func TestProcessValSetterBadArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{name: "walk sd < 0", f: func() {
+			datagen.NewRandomWalkValSetter[int](0, -1)
+		}},
+		{name: "OU rate 0", f: func() {
+			datagen.NewOUValSetter[int](0, 0, 1)
+		}},
+		{name: "GBM drift -1", f: func() {
+			datagen.NewGBMValSetter[float64](-1, 0.1)
+		}},
+		{name: "bounds min == max", f: func() {
+			datagen.NewRandomWalkValSetter(0, 1,
+				datagen.ProcessValSetterSetBounds(1, 1))
+		}},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: a panic was expected", tc.name)
+				}
+			}()
+
+			tc.f()
+		}()
+	}
+}
@@ -2,6 +2,7 @@ package datagen
 
 import (
 	"math/rand/v2"
+	"sync"
 )
 
 // NewRand returns a new rand.Rand with its own unique source, suitable for
@@ -12,3 +13,73 @@ import (
 func NewRand() *rand.Rand {
 	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
 }
+
+// Seeder records a master seed from which it derives the seeds for the
+// random number generators used by the various generators and value
+// setters. Two Seeders constructed with the same master seed will produce
+// the same sequence of random number generators so, provided the generators
+// are constructed in the same order, the same data will be generated.
+//
+// It is safe to use a Seeder from multiple goroutines though the order in
+// which the random number generators are requested will then be
+// unpredictable and so the generated data will be too.
+type Seeder struct {
+	mtx   sync.Mutex
+	seed  uint64
+	count uint64
+}
+
+// NewSeeder returns a new Seeder with the master seed set to the supplied
+// value.
+func NewSeeder(seed uint64) *Seeder {
+	return &Seeder{seed: seed}
+}
+
+// Seed returns the master seed from which the Seeder derives its sub-seeds.
+func (s *Seeder) Seed() uint64 {
+	return s.seed
+}
+
+// splitMix64 returns a well-mixed value derived from v. It is the
+// finalisation step of the SplitMix64 generator and is used to derive
+// sub-seeds which are uncorrelated even when the inputs differ only
+// slightly.
+func splitMix64(v uint64) uint64 {
+	v += 0x9e3779b97f4a7c15
+	v = (v ^ (v >> 30)) * 0xbf58476d1ce4e5b9 //nolint:mnd
+	v = (v ^ (v >> 27)) * 0x94d049bb133111eb //nolint:mnd
+
+	return v ^ (v >> 31) //nolint:mnd
+}
+
+// subSeeds returns the pair of seeds for the n'th random number generator
+// derived from the master seed.
+func (s *Seeder) subSeeds(n uint64) (uint64, uint64) {
+	base := splitMix64(s.seed ^ splitMix64(n))
+
+	return splitMix64(base), splitMix64(base + 1)
+}
+
+// deriveSalt is mixed into the master seed when deriving a new Seeder so
+// that the derived master seeds are unrelated to the sub-seeds
+const deriveSalt = 0x5ca1ab1e0ddba11
+
+// Derive returns a new Seeder whose master seed is derived from this
+// Seeder's master seed and the supplied value. The result depends only on
+// the master seed and n and not on any other use of this Seeder. This can
+// be used to give independent but reproducible random streams to, for
+// instance, shards of a dataset generated in parallel.
+func (s *Seeder) Derive(n uint64) *Seeder {
+	return NewSeeder(splitMix64(splitMix64(s.seed^deriveSalt) + n))
+}
+
+// NewRand returns a new rand.Rand whose source is seeded with the next
+// sub-seed derived from the master seed.
+func (s *Seeder) NewRand() *rand.Rand {
+	s.mtx.Lock()
+	n := s.count
+	s.count++
+	s.mtx.Unlock()
+
+	return rand.New(rand.NewPCG(s.subSeeds(n))) //nolint:gosec
+}
@@ -0,0 +1,89 @@
+package datagen_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// seededRecord returns a Record whose random fields all take their random
+// number generators from the Seeder
+func seededRecord(s *datagen.Seeder) *datagen.Record {
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	return datagen.NewRecord("r",
+		datagen.NewField("when", datagen.NewTimeGen(
+			datagen.TimeGenSetInitialTime(start),
+			datagen.TimeGenSetIntervalF(
+				datagen.TimeGenConstIntervalF(time.Minute)),
+		)),
+		datagen.NewField("gap", datagen.NewGen(
+			datagen.GenSetValSetter[time.Time](
+				datagen.NewTimeValSetGaussianInterval(60, 10, time.Second, true,
+					datagen.TimeValSetGaussianIntervalSetSeeder(s))),
+			datagen.GenSetValue(start),
+		)),
+		datagen.NewField("qty", datagen.NewGen(
+			datagen.GenSetValSetter[int](
+				datagen.NewNormValSetter(1, 100, 50, 20,
+					datagen.NormValSetterSetSeeder[int](s))),
+		)),
+		datagen.NewField("status", datagen.NewWStringGenWithOpts(
+			datagen.Random,
+			[]datagen.WeightedString{
+				{Str: "open", Weight: 3},
+				{Str: "closed", Weight: 1},
+				{Str: "held", Weight: 1},
+			},
+			datagen.WStringGenSetSeeder(s))),
+	)
+}
+
+// generateRows returns the rows generated by the Record, joined into a
+// single string
+func generateRows(r *datagen.Record, rows int) string {
+	var sb strings.Builder
+
+	for range rows {
+		sb.WriteString(strings.Join(r.Generate(), ","))
+		sb.WriteString("\n")
+		r.Next()
+	}
+
+	return sb.String()
+}
+
+func TestSeederReproducible(t *testing.T) {
+	const rows = 50
+
+	first := generateRows(seededRecord(datagen.NewSeeder(42)), rows)
+	second := generateRows(seededRecord(datagen.NewSeeder(42)), rows)
+
+	if first != second {
+		t.Errorf("the same seed gave different output:\n%s\nand:\n%s",
+			first, second)
+	}
+
+	other := generateRows(seededRecord(datagen.NewSeeder(43)), rows)
+	if first == other {
+		t.Error("different seeds gave the same output")
+	}
+}
+
+func TestSeederDerive(t *testing.T) {
+	s := datagen.NewSeeder(42)
+
+	a := s.Derive(1).NewRand().Uint64()
+
+	s.NewRand() // using the parent must not change the derived Seeders
+
+	if b := s.Derive(1).NewRand().Uint64(); a != b {
+		t.Errorf("Derive(1) gave different streams: %d and %d", a, b)
+	}
+
+	if c := s.Derive(2).NewRand().Uint64(); a == c {
+		t.Error("Derive(1) and Derive(2) gave the same stream")
+	}
+}
@@ -1,6 +1,12 @@
 package datagen
 
-// Record describes a record
+import "fmt"
+
+// Record describes a record. The current values of its fields give the
+// next row to be produced. Every row produced by the Record writers and the
+// Rows iterators is generated from the current values and the Record is
+// then moved on to its next value, so successive calls carry on from where
+// the last one stopped without repeating a row.
 type Record struct {
 	name   string
 	fields []*Field
@@ -12,6 +18,23 @@ func NewRecord(name string, f ...*Field) *Record {
 	return &Record{name: name, fields: f}
 }
 
+// Name returns the record name
+func (r Record) Name() string {
+	return r.name
+}
+
+// Field returns the field with the given name and true if it is in the
+// record, otherwise it returns nil and false.
+func (r Record) Field(name string) (*Field, bool) {
+	for _, f := range r.fields {
+		if f.Name() == name {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
 // AddFields adds the passed fields to the record
 func (r *Record) AddFields(f ...*Field) {
 	r.fields = append(r.fields, f...)
@@ -48,9 +71,49 @@ func (r Record) GenerateAsMap() map[string]string {
 	return rval
 }
 
+// GenerateValues will return a slice of the values of the fields. Where
+// the field's Generator has a Value method the typed value is given,
+// otherwise the generated string is used. If the field's value is NULL
+// (see the Nullable interface) the value is nil.
+func (r Record) GenerateValues() []any {
+	rval := make([]any, 0, len(r.fields))
+	for _, f := range r.fields {
+		if isNull(f.g) {
+			rval = append(rval, nil)
+			continue
+		}
+
+		v, ok := typedValue(f.g)
+		if !ok {
+			v = f.g.Generate()
+		}
+
+		rval = append(rval, v)
+	}
+
+	return rval
+}
+
 // Next moves all the fields to their next value
 func (r Record) Next() {
 	for _, f := range r.fields {
 		f.g.Next()
 	}
 }
+
+// Err returns an error if any of the fields' Generators reports an error
+// (see the ErrReporter interface). The error for the first such field is
+// returned. The Record writers check this after generating each row;
+// callers using the Rows iterators or calling Next directly should check it
+// themselves.
+func (r Record) Err() error {
+	for _, f := range r.fields {
+		if er, ok := f.g.(ErrReporter); ok {
+			if err := er.Err(); err != nil {
+				return fmt.Errorf("field %q: %w", f.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package datagen
+
+import "iter"
+
+// recordSeq returns an iterator over the rows of the Record, each row being
+// given by the gen func. After each row has been yielded the Record is
+// moved on to its next value (see Record). If limit is negative there is
+// no limit on the number of rows and the iteration continues until the
+// consumer stops.
+func recordSeq[T any](r *Record, limit int, gen func() T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := 0; limit < 0 || i < limit; i++ {
+			more := yield(i, gen())
+
+			r.Next()
+
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+// Rows returns an iterator over up to limit rows of the Record. Each row is
+// given with its index (starting at 0) as a slice of strings in the order
+// of the fields. If limit is negative the rows are generated until the
+// consumer stops.
+func (r *Record) Rows(limit int) iter.Seq2[int, []string] {
+	return recordSeq(r, limit, r.Generate)
+}
+
+// RowMaps returns an iterator over up to limit rows of the Record. Each row
+// is given with its index (starting at 0) as a map of field names to
+// generated strings. If limit is negative the rows are generated until the
+// consumer stops.
+func (r *Record) RowMaps(limit int) iter.Seq2[int, map[string]string] {
+	return recordSeq(r, limit, r.GenerateAsMap)
+}
+
+// RowValues returns an iterator over up to limit rows of the Record. Each
+// row is given with its index (starting at 0) as a slice of the typed
+// values of the fields, as given by GenerateValues. If limit is negative
+// the rows are generated until the consumer stops.
+func (r *Record) RowValues(limit int) iter.Seq2[int, []any] {
+	return recordSeq(r, limit, r.GenerateValues)
+}
+
+// Titles returns an iterator over the names of the fields in the Record.
+func (r *Record) Titles() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, f := range r.fields {
+			if !yield(f.Name()) {
+				return
+			}
+		}
+	}
+}
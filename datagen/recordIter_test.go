@@ -0,0 +1,100 @@
+package datagen_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+func TestRows(t *testing.T) {
+	r := datagen.NewRecord("r",
+		datagen.NewField("a", incrGen(0, 1)),
+		datagen.NewField("b", incrGen(0, 10)))
+
+	var got [][]string
+	for i, row := range r.Rows(3) {
+		if i != len(got) {
+			t.Errorf("bad index: expected %d, got %d", len(got), i)
+		}
+
+		got = append(got, row)
+	}
+
+	exp := [][]string{{"0", "0"}, {"1", "10"}, {"2", "20"}}
+	if !slices.EqualFunc(got, exp, slices.Equal) {
+		t.Errorf("bad rows\nexpected: %v\n     got: %v", exp, got)
+	}
+
+	for _, m := range r.RowMaps(1) {
+		if m["a"] != "3" || m["b"] != "30" {
+			t.Errorf("bad row map: %v", m)
+		}
+	}
+
+	for _, vals := range r.RowValues(1) {
+		if vals[0] != 4 || vals[1] != 40 {
+			t.Errorf("bad row values: %v", vals)
+		}
+	}
+
+	titles := slices.Collect(r.Titles())
+	if !slices.Equal(titles, []string{"a", "b"}) {
+		t.Errorf("bad titles: %v", titles)
+	}
+}
+
+func TestRowsUnlimited(t *testing.T) {
+	r := datagen.NewRecord("r", datagen.NewField("a", incrGen(0, 1)))
+
+	count := 0
+
+	for i := range r.Rows(-1) {
+		count++
+
+		if i == 99 {
+			break
+		}
+	}
+
+	if count != 100 {
+		t.Errorf("expected 100 rows, got %d", count)
+	}
+}
+
+// TestRowsAndWritersCarryOn checks that the writers and the iterators
+// follow the same rule for moving the Record on so that successive calls
+// neither repeat nor skip a row
+func TestRowsAndWritersCarryOn(t *testing.T) {
+	r := datagen.NewRecord("r", datagen.NewField("a", incrGen(0, 1)))
+	cw := datagen.NewCSVWriter(datagen.CSVWriterSetShowTitles(false))
+
+	var got []string
+
+	for range 2 {
+		var buf bytes.Buffer
+		if err := cw.Write(&buf, r, 2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, strings.Fields(buf.String())...)
+	}
+
+	for _, row := range r.Rows(2) {
+		got = append(got, row...)
+	}
+
+	var buf bytes.Buffer
+	if err := cw.Write(&buf, r, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got = append(got, strings.Fields(buf.String())...)
+
+	exp := []string{"0", "1", "2", "3", "4", "5", "6"}
+	if !slices.Equal(got, exp) {
+		t.Errorf("bad values\nexpected: %v\n     got: %v", exp, got)
+	}
+}
@@ -0,0 +1,49 @@
+package datagen
+
+import "io"
+
+// RowWriter is the interface wrapping the methods needed to write the rows
+// of a Record one at a time. WriteRow writes a row generated from the
+// current values of the Record; it does not move the Record on to its next
+// value so the caller should call the Record's Next method after each row
+// (see Record). Close writes anything needed to complete the output (such as a
+// closing bracket or the end of a statement) and must be called once all
+// the rows have been written. Any heading is written before the first row
+// or, if there are no rows, by Close.
+type RowWriter interface {
+	WriteRow() error
+	Close() error
+}
+
+// RecordWriter is the interface wrapping the methods shared by the
+// CSVWriter, JSONWriter and SQLWriter. Write writes a given number of rows
+// and NewRowWriter returns a RowWriter allowing the caller to control when
+// rows are written.
+//
+// NewPartRowWriter returns a RowWriter which writes one part of a larger
+// output, such as a shard of a dataset generated in parallel (see
+// ShardGen). The rows it writes are taken to start at firstRow in the
+// complete output so any separators and statement breaks are written as if
+// all the rows had been written by a single RowWriter. Any heading is only
+// written if firstRow is 0 and Close only completes the output if last is
+// true, so the parts can be joined to give the complete output.
+type RecordWriter interface {
+	Write(w io.Writer, r *Record, rows int) error
+	NewRowWriter(w io.Writer, r *Record) RowWriter
+	NewPartRowWriter(w io.Writer, r *Record, firstRow int, last bool) RowWriter
+}
+
+// writeRows writes the given number of rows to the RowWriter. Each row is
+// generated from the current values of the Record which is then moved on to
+// its next value.
+func writeRows(rw RowWriter, r *Record, rows int) error {
+	for range rows {
+		if err := rw.WriteRow(); err != nil {
+			return err
+		}
+
+		r.Next()
+	}
+
+	return rw.Close()
+}
@@ -0,0 +1,216 @@
+package datagen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"runtime"
+)
+
+const dfltShardSize = 10000
+
+// ShardInfo describes a shard of a dataset
+type ShardInfo struct {
+	// Index is the position of the shard in the dataset, starting at 0
+	Index int
+	// FirstRow is the index in the dataset of the first row of the shard
+	FirstRow int
+	// Rows is the number of rows in the shard
+	Rows int
+	// Seeder should be used to construct all the random generators and value
+	// setters for the shard. It is derived from the ShardGen's Seeder and
+	// the shard Index.
+	Seeder *Seeder
+}
+
+// RecordMaker is the type of a function which constructs the Record for a
+// shard. Each call must return a new Record with new Generators; sequential
+// fields should start at the value for the shard's FirstRow (see, for
+// instance, IncrementingValSetter.Nth) and random Generators should be
+// constructed using the shard's Seeder.
+type RecordMaker func(si ShardInfo) (*Record, error)
+
+// ShardGen records the information needed to generate a dataset in shards
+// on several goroutines. The division of the dataset into shards depends
+// only on the shard size and not on the number of workers so, given the
+// same Seeder, the same data is generated regardless of the number of
+// workers.
+type ShardGen struct {
+	workers   int
+	shardSize int
+	seeder    *Seeder
+}
+
+// ShardGenOptFunc is the type of an option-setting function that will set a
+// value in a ShardGen
+type ShardGenOptFunc func(sg *ShardGen) error
+
+// ShardGenSetWorkers returns a ShardGen Opt function which sets the number of
+// shards that can be generated at the same time. The default is the value
+// of GOMAXPROCS.
+func ShardGenSetWorkers(n int) ShardGenOptFunc {
+	return func(sg *ShardGen) error {
+		if n <= 0 {
+			return fmt.Errorf("the number of workers (%d) must be > 0", n)
+		}
+
+		sg.workers = n
+
+		return nil
+	}
+}
+
+// ShardGenSetShardSize returns a ShardGen Opt function which sets the
+// number of rows in each shard (the last shard may be smaller). The default
+// is 10000.
+func ShardGenSetShardSize(n int) ShardGenOptFunc {
+	return func(sg *ShardGen) error {
+		if n <= 0 {
+			return fmt.Errorf("the shard size (%d) must be > 0", n)
+		}
+
+		sg.shardSize = n
+
+		return nil
+	}
+}
+
+// ShardGenSetSeeder returns a ShardGen Opt function which sets the Seeder
+// from which the Seeder for each shard is derived. If this is not set a
+// Seeder with a random master seed is used.
+func ShardGenSetSeeder(s *Seeder) ShardGenOptFunc {
+	return func(sg *ShardGen) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		sg.seeder = s
+
+		return nil
+	}
+}
+
+// NewShardGen creates a new ShardGen object. It will panic if any of the
+// option functions returns an error.
+func NewShardGen(opts ...ShardGenOptFunc) *ShardGen {
+	sg := &ShardGen{
+		workers:   runtime.GOMAXPROCS(0),
+		shardSize: dfltShardSize,
+	}
+
+	for _, o := range opts {
+		if err := o(sg); err != nil {
+			panic(err)
+		}
+	}
+
+	if sg.seeder == nil {
+		sg.seeder = NewSeeder(rand.Uint64()) //nolint:gosec
+	}
+
+	return sg
+}
+
+// Shards returns the ShardInfo for each of the shards of a dataset with the
+// given number of rows.
+func (sg ShardGen) Shards(rows int) []ShardInfo {
+	shards := make([]ShardInfo, 0, (rows+sg.shardSize-1)/sg.shardSize)
+
+	for first := 0; first < rows; first += sg.shardSize {
+		idx := len(shards)
+		shards = append(shards, ShardInfo{
+			Index:    idx,
+			FirstRow: first,
+			Rows:     min(sg.shardSize, rows-first),
+			Seeder:   sg.seeder.Derive(uint64(idx)),
+		})
+	}
+
+	return shards
+}
+
+// shardResult holds the output from generating a shard
+type shardResult struct {
+	buf bytes.Buffer
+	err error
+}
+
+// genShard generates the shard and writes it to the result buffer as the
+// part of the complete output starting at the shard's first row
+func genShard(si ShardInfo, last bool,
+	mk RecordMaker, rw RecordWriter,
+) *shardResult {
+	res := &shardResult{}
+
+	r, err := mk(si)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	res.err = writeRows(
+		rw.NewPartRowWriter(&res.buf, r, si.FirstRow, last), r, si.Rows)
+
+	return res
+}
+
+// Write generates the given number of rows, divided into shards, and
+// writes them to the io.Writer in order. Each shard is generated on its own
+// goroutine with up to the configured number of workers running at the same
+// time. Each shard is written as a part of the complete output (see
+// RecordWriter) so that any heading or ending, such as CSV titles or the
+// brackets around a JSON array, is only written once and INSERT statements
+// are broken at the same rows as if the output had been written in one
+// piece. The first error encountered is returned and no further shards are
+// written.
+func (sg ShardGen) Write(w io.Writer, rows int,
+	mk RecordMaker, rw RecordWriter,
+) error {
+	shards := sg.Shards(rows)
+	if len(shards) == 0 {
+		// an empty shard is written so that any heading and ending are
+		// still written
+		shards = []ShardInfo{{Seeder: sg.seeder.Derive(0)}}
+	}
+
+	results := make([]chan *shardResult, len(shards))
+	for i := range results {
+		results[i] = make(chan *shardResult, 1)
+	}
+
+	sem := make(chan struct{}, sg.workers)
+	done := make(chan struct{})
+
+	defer close(done)
+
+	go func() {
+		for i, si := range shards {
+			select {
+			case sem <- struct{}{}:
+			case <-done:
+				return
+			}
+
+			go func() {
+				results[i] <- genShard(si, i == len(shards)-1, mk, rw)
+			}()
+		}
+	}()
+
+	for i := range shards {
+		res := <-results[i]
+		if res.err != nil {
+			return fmt.Errorf("shard %d: %w", i, res.err)
+		}
+
+		if _, err := w.Write(res.buf.Bytes()); err != nil {
+			return err
+		}
+
+		<-sem
+	}
+
+	return nil
+}
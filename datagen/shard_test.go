@@ -0,0 +1,111 @@
+package datagen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// shardRecord is a RecordMaker giving a sequential id starting at the
+// shard's first row and a random quantity from the shard's Seeder
+func shardRecord(si datagen.ShardInfo) (*datagen.Record, error) {
+	return datagen.NewRecord("r",
+		datagen.NewField("id", incrGen(si.FirstRow, 1)),
+		datagen.NewField("qty", datagen.NewGen(
+			datagen.GenSetValSetter[int](
+				datagen.NewNormValSetter(1, 100, 50, 20,
+					datagen.NormValSetterSetSeeder[int](si.Seeder))),
+		)),
+	), nil
+}
+
+// shardWrite writes the rows using a ShardGen with the given number of
+// workers and returns the output
+func shardWrite(t *testing.T,
+	workers, rows int, rw datagen.RecordWriter,
+) string {
+	t.Helper()
+
+	sg := datagen.NewShardGen(
+		datagen.ShardGenSetWorkers(workers),
+		datagen.ShardGenSetShardSize(7),
+		datagen.ShardGenSetSeeder(datagen.NewSeeder(42)))
+
+	var buf bytes.Buffer
+	if err := sg.Write(&buf, rows, shardRecord, rw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestShardGenWorkers(t *testing.T) {
+	testCases := []struct {
+		name string
+		rw   datagen.RecordWriter
+	}{
+		{name: "CSV", rw: datagen.NewCSVWriter()},
+		{name: "JSON Lines", rw: datagen.NewJSONWriter()},
+		{
+			name: "JSON array",
+			rw:   datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)),
+		},
+		{
+			name: "SQL insert",
+			rw:   datagen.NewSQLWriter(datagen.SQLWriterSetBatchSize(5)),
+		},
+		{
+			name: "SQL copy",
+			rw:   datagen.NewSQLWriter(datagen.SQLWriterSetStyle(datagen.SQLCopy)),
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, rows := range []int{0, 1, 7, 30} {
+			exp := shardWrite(t, 1, rows, tc.rw)
+
+			if got := shardWrite(t, 4, rows, tc.rw); got != exp {
+				t.Errorf("%s, %d rows: 4 workers differ from 1"+
+					"\nexpected: %q\n     got: %q", tc.name, rows, exp, got)
+			}
+		}
+	}
+}
+
+// TestShardGenWholeOutput checks that the shards join to give the same
+// output as a single writer would
+func TestShardGenWholeOutput(t *testing.T) {
+	const rows = 30
+
+	out := shardWrite(t, 4, rows,
+		datagen.NewJSONWriter(datagen.JSONWriterSetArray(true)))
+
+	var objs []map[string]any
+	if err := json.Unmarshal([]byte(out), &objs); err != nil {
+		t.Fatalf("the output is not valid JSON: %v\n%s", err, out)
+	}
+
+	if len(objs) != rows {
+		t.Errorf("expected %d objects, got %d", rows, len(objs))
+	}
+
+	for i, o := range objs {
+		if o["id"] != float64(i) {
+			t.Errorf("object %d: bad id: %v", i, o["id"])
+		}
+	}
+
+	out = shardWrite(t, 4, rows,
+		datagen.NewSQLWriter(datagen.SQLWriterSetBatchSize(4)))
+	if n := strings.Count(out, "INSERT INTO"); n != 8 {
+		t.Errorf("expected 8 INSERT statements, got %d:\n%s", n, out)
+	}
+
+	out = shardWrite(t, 4, rows, datagen.NewCSVWriter())
+	if n := strings.Count(out, "id,qty"); n != 1 {
+		t.Errorf("expected 1 title row, got %d:\n%s", n, out)
+	}
+}
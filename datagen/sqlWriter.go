@@ -0,0 +1,470 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dfltSQLBatchSize = 100
+	sqlTimeLayout    = "2006-01-02 15:04:05.999999999-07:00"
+)
+
+// SQLStyle encodes the form of the SQL statements to be generated
+type SQLStyle int
+
+// SQLInsert means that the rows should be written as INSERT statements
+// which can be loaded into most databases.
+//
+// SQLCopy means that the rows should be written as a Postgres COPY ... FROM
+// stdin block.
+const (
+	SQLInsert SQLStyle = iota
+	SQLCopy
+)
+
+// SQLType gives a hint as to how the values of a field should be written
+type SQLType int
+
+// SQLTypeAuto means that the way the value is written is chosen from the
+// type of the value given by the Value method of the field's Generator. If
+// the Generator has no Value method, or the type is not recognised, the
+// value is written as text.
+//
+// SQLTypeText means that the value is written as a quoted string.
+//
+// SQLTypeNumeric means that the value is written without quotes. The
+// field's Generator must have a Value method giving an integer, a float or
+// a Money value; anything else is reported as an error when the row is
+// written.
+//
+// SQLTypeBool means that the value is written as TRUE or FALSE. The field's
+// Generator must have a Value method giving a bool; anything else is
+// reported as an error when the row is written.
+//
+// SQLTypeTimestamp means that the value is written as a quoted timestamp.
+const (
+	SQLTypeAuto SQLType = iota
+	SQLTypeText
+	SQLTypeNumeric
+	SQLTypeBool
+	SQLTypeTimestamp
+)
+
+// SQLWriter records the information needed to write the values generated
+// by a Record as SQL statements.
+type SQLWriter struct {
+	style      SQLStyle
+	table      string
+	batchSize  int
+	fieldTypes map[string]SQLType
+}
+
+// SQLWriterOptFunc is the type of an option-setting function that will set
+// a value in a SQLWriter
+type SQLWriterOptFunc func(sw *SQLWriter) error
+
+// SQLWriterSetStyle returns a SQLWriter Opt function which sets the style of
+// the generated SQL. The default is SQLInsert.
+func SQLWriterSetStyle(style SQLStyle) SQLWriterOptFunc {
+	return func(sw *SQLWriter) error {
+		if style != SQLInsert && style != SQLCopy {
+			return fmt.Errorf("bad SQLStyle: %d", style)
+		}
+
+		sw.style = style
+
+		return nil
+	}
+}
+
+// SQLWriterSetTable returns a SQLWriter Opt function which sets the name of
+// the table to be loaded. The default is to use the name of the Record.
+func SQLWriterSetTable(table string) SQLWriterOptFunc {
+	return func(sw *SQLWriter) error {
+		if table == "" {
+			return errors.New("the table name must not be empty")
+		}
+
+		sw.table = table
+
+		return nil
+	}
+}
+
+// SQLWriterSetBatchSize returns a SQLWriter Opt function which sets the
+// maximum number of rows given in each INSERT statement. The default is 100.
+func SQLWriterSetBatchSize(n int) SQLWriterOptFunc {
+	return func(sw *SQLWriter) error {
+		if n <= 0 {
+			return fmt.Errorf("the batch size (%d) must be > 0", n)
+		}
+
+		sw.batchSize = n
+
+		return nil
+	}
+}
+
+// SQLWriterSetFieldType returns a SQLWriter Opt function which sets the type
+// hint for the named field. Fields without a type hint are treated as
+// SQLTypeAuto.
+func SQLWriterSetFieldType(name string, t SQLType) SQLWriterOptFunc {
+	return func(sw *SQLWriter) error {
+		if t < SQLTypeAuto || t > SQLTypeTimestamp {
+			return fmt.Errorf("bad SQLType for field %q: %d", name, t)
+		}
+
+		sw.fieldTypes[name] = t
+
+		return nil
+	}
+}
+
+// NewSQLWriter creates a new SQLWriter object. By default it writes INSERT
+// statements of up to 100 rows into a table with the same name as the
+// Record. It will panic if any of the option functions returns an error.
+func NewSQLWriter(opts ...SQLWriterOptFunc) *SQLWriter {
+	sw := &SQLWriter{
+		batchSize:  dfltSQLBatchSize,
+		fieldTypes: map[string]SQLType{},
+	}
+
+	for _, o := range opts {
+		if err := o(sw); err != nil {
+			panic(err)
+		}
+	}
+
+	return sw
+}
+
+// sqlIdent returns the name as a quoted SQL identifier. Names are always
+// quoted so that names which are SQL keywords can be used.
+func sqlIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlVal records the value of a field in a form that can be written as
+// SQL.
+type sqlVal struct {
+	s      string
+	quote  bool
+	isNull bool
+}
+
+// sqlAutoVal returns the sqlVal for the field's Generator, choosing the
+// format from the type of its value.
+func sqlAutoVal(g Generator) sqlVal {
+	v, ok := typedValue(g)
+	if !ok {
+		return sqlVal{s: g.Generate(), quote: true}
+	}
+
+	switch tv := v.(type) {
+	case time.Time:
+		return sqlVal{s: tv.Format(sqlTimeLayout), quote: true}
+	case Money:
+		return sqlVal{s: tv.decimalString()}
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return sqlVal{s: strings.ToUpper(strconv.FormatBool(rv.Bool()))}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sqlVal{s: strconv.FormatInt(rv.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		return sqlVal{s: strconv.FormatUint(rv.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return sqlVal{s: strconv.FormatFloat(f, 'g', -1, 64), quote: true}
+		}
+
+		return sqlVal{s: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+
+	return sqlVal{s: g.Generate(), quote: true}
+}
+
+// sqlValKind returns the kind of the typed value of the Generator or
+// reflect.Invalid if it has no typed value. Money values are given as
+// reflect.Float64 as they are written as decimal numbers.
+func sqlValKind(g Generator) reflect.Kind {
+	v, ok := typedValue(g)
+	if !ok || v == nil {
+		return reflect.Invalid
+	}
+
+	if _, ok := v.(Money); ok {
+		return reflect.Float64
+	}
+
+	return reflect.ValueOf(v).Kind()
+}
+
+// fieldVal returns the sqlVal for the field. An error is returned if the
+// field's value does not match its type hint.
+func (sw SQLWriter) fieldVal(f *Field) (sqlVal, error) {
+	if isNull(f.g) {
+		return sqlVal{isNull: true}, nil
+	}
+
+	switch sw.fieldTypes[f.Name()] {
+	case SQLTypeText:
+		return sqlVal{s: f.g.Generate(), quote: true}, nil
+	case SQLTypeNumeric:
+		switch sqlValKind(f.g) {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+			reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+			reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return sqlAutoVal(f.g), nil
+		}
+
+		return sqlVal{}, fmt.Errorf("field %q: the value is not numeric"+
+			" so it cannot be written as SQLTypeNumeric", f.Name())
+	case SQLTypeBool:
+		if sqlValKind(f.g) == reflect.Bool {
+			return sqlAutoVal(f.g), nil
+		}
+
+		return sqlVal{}, fmt.Errorf("field %q: the value is not a bool"+
+			" so it cannot be written as SQLTypeBool", f.Name())
+	case SQLTypeTimestamp:
+		if v, ok := typedValue(f.g); ok {
+			if t, ok := v.(time.Time); ok {
+				return sqlVal{s: t.Format(sqlTimeLayout), quote: true}, nil
+			}
+		}
+
+		return sqlVal{s: f.g.Generate(), quote: true}, nil
+	}
+
+	return sqlAutoVal(f.g), nil
+}
+
+// insertStr returns the value formatted for an INSERT statement
+func (val sqlVal) insertStr() string {
+	if val.isNull {
+		return "NULL"
+	}
+
+	if val.quote {
+		return "'" + strings.ReplaceAll(val.s, "'", "''") + "'"
+	}
+
+	return val.s
+}
+
+var copyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// copyStr returns the value formatted for a Postgres COPY block
+func (val sqlVal) copyStr() string {
+	if val.isNull {
+		return `\N`
+	}
+
+	return copyEscaper.Replace(val.s)
+}
+
+// checkFields returns an error if any of the field type hints refers to a
+// field that is not in the Record.
+func (sw SQLWriter) checkFields(r *Record) error {
+	names := make(map[string]bool, len(r.fields))
+	for _, f := range r.fields {
+		names[f.Name()] = true
+	}
+
+	for name := range sw.fieldTypes {
+		if !names[name] {
+			return fmt.Errorf("a type hint is given for field %q"+
+				" which is not in record %q", name, r.Name())
+		}
+	}
+
+	return nil
+}
+
+// tableAndCols returns the table name and the comma-separated list of
+// column names
+func (sw SQLWriter) tableAndCols(r *Record) (string, string, error) {
+	table := sw.table
+	if table == "" {
+		table = r.Name()
+	}
+
+	if table == "" {
+		return "", "", errors.New(
+			"no table name is given and the record has no name")
+	}
+
+	cols := make([]string, 0, len(r.fields))
+	for _, f := range r.fields {
+		cols = append(cols, sqlIdent(f.Name()))
+	}
+
+	return sqlIdent(table), strings.Join(cols, ", "), nil
+}
+
+// sqlRowWriter writes the rows of a Record as SQL. It implements the
+// RowWriter interface.
+type sqlRowWriter struct {
+	sw      SQLWriter
+	w       io.Writer
+	r       *Record
+	err     error
+	table   string
+	cols    string
+	vals    []string
+	started bool
+	count   int
+	last    bool
+}
+
+// NewRowWriter returns a RowWriter which will write the rows of the Record
+// to the io.Writer. Any problem with the SQLWriter's configuration for the
+// Record is reported by the first call to WriteRow or Close.
+func (sw SQLWriter) NewRowWriter(w io.Writer, r *Record) RowWriter {
+	return sw.NewPartRowWriter(w, r, 0, true)
+}
+
+// NewPartRowWriter returns a RowWriter which will write part of the rows of
+// the Record to the io.Writer (see RecordWriter). The INSERT statements are
+// broken at the same rows as for the complete output, the COPY heading is
+// only written for the part starting at row 0 and the final statement is
+// only completed by the last part.
+func (sw SQLWriter) NewPartRowWriter(w io.Writer, r *Record,
+	firstRow int, last bool,
+) RowWriter {
+	srw := &sqlRowWriter{
+		sw:      sw,
+		w:       w,
+		r:       r,
+		vals:    make([]string, len(r.fields)),
+		started: firstRow > 0,
+		count:   firstRow,
+		last:    last,
+	}
+
+	if srw.err = sw.checkFields(r); srw.err == nil {
+		srw.table, srw.cols, srw.err = sw.tableAndCols(r)
+	}
+
+	return srw
+}
+
+// start writes the COPY heading if it is needed and has not yet been
+// written
+func (srw *sqlRowWriter) start() error {
+	if srw.started || srw.sw.style != SQLCopy {
+		return nil
+	}
+
+	srw.started = true
+
+	_, err := fmt.Fprintf(srw.w, "COPY %s (%s) FROM stdin;\n",
+		srw.table, srw.cols)
+
+	return err
+}
+
+// WriteRow writes a row generated from the current values of the Record,
+// either as part of an INSERT statement or as a line of a COPY block. Any
+// error encountered while writing, or reported by the Record, is returned.
+func (srw *sqlRowWriter) WriteRow() error {
+	if srw.err != nil {
+		return srw.err
+	}
+
+	if err := srw.start(); err != nil {
+		return err
+	}
+
+	for j, f := range srw.r.fields {
+		val, err := srw.sw.fieldVal(f)
+		if err != nil {
+			return err
+		}
+
+		if srw.sw.style == SQLCopy {
+			srw.vals[j] = val.copyStr()
+		} else {
+			srw.vals[j] = val.insertStr()
+		}
+	}
+
+	if err := srw.r.Err(); err != nil {
+		return err
+	}
+
+	var s string
+
+	switch {
+	case srw.sw.style == SQLCopy:
+		s = strings.Join(srw.vals, "\t") + "\n"
+	case srw.count%srw.sw.batchSize == 0:
+		s = fmt.Sprintf("INSERT INTO %s (%s) VALUES\n(%s)",
+			srw.table, srw.cols, strings.Join(srw.vals, ", "))
+	default:
+		s = ",\n(" + strings.Join(srw.vals, ", ") + ")"
+	}
+
+	srw.count++
+
+	if srw.sw.style == SQLInsert && srw.count%srw.sw.batchSize == 0 {
+		s += ";\n"
+	}
+
+	_, err := io.WriteString(srw.w, s)
+
+	return err
+}
+
+// Close completes any unfinished INSERT statement or COPY block, unless
+// this is not the last part of the output
+func (srw *sqlRowWriter) Close() error {
+	if srw.err != nil || !srw.last {
+		return srw.err
+	}
+
+	if err := srw.start(); err != nil {
+		return err
+	}
+
+	end := ""
+
+	switch {
+	case srw.sw.style == SQLCopy:
+		end = "\\.\n"
+	case srw.count%srw.sw.batchSize != 0:
+		end = ";\n"
+	}
+
+	_, err := io.WriteString(srw.w, end)
+
+	return err
+}
+
+// Write writes the given number of rows generated by the Record to the
+// io.Writer as SQL. The first row is generated from the current values of
+// the Record which is moved on to its next value after each row (see
+// Record). Any error encountered while writing, or reported by the Record,
+// is returned.
+func (sw SQLWriter) Write(w io.Writer, r *Record, rows int) error {
+	return writeRows(sw.NewRowWriter(w, r), r, rows)
+}
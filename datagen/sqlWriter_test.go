@@ -0,0 +1,166 @@
+package datagen_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// sqlTestRecord returns a Record with an int, a string, a bool and a time
+// field
+func sqlTestRecord() *datagen.Record {
+	return datagen.NewRecord("orders",
+		datagen.NewField("id", incrGen(1, 1)),
+		datagen.NewField("note", datagen.NewGen(datagen.GenSetValue("it's\tok"))),
+		datagen.NewField("paid", datagen.NewGen(datagen.GenSetValue(true))),
+		datagen.NewField("when", datagen.NewGen(datagen.GenSetValue(
+			time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC)))),
+	)
+}
+
+func TestSQLWriter(t *testing.T) {
+	const cols = `"id", "note", "paid", "when"`
+
+	testCases := []struct {
+		name    string
+		sw      *datagen.SQLWriter
+		rows    int
+		expText string
+	}{
+		{
+			name: "insert, batches of 2",
+			sw:   datagen.NewSQLWriter(datagen.SQLWriterSetBatchSize(2)),
+			rows: 3,
+			expText: `INSERT INTO "orders" (` + cols + ") VALUES\n" +
+				"(1, 'it''s\tok', TRUE, '2024-03-01 09:30:00+00:00'),\n" +
+				"(2, 'it''s\tok', TRUE, '2024-03-01 09:30:00+00:00');\n" +
+				`INSERT INTO "orders" (` + cols + ") VALUES\n" +
+				"(3, 'it''s\tok', TRUE, '2024-03-01 09:30:00+00:00');\n",
+		},
+		{
+			name: "copy",
+			sw: datagen.NewSQLWriter(
+				datagen.SQLWriterSetStyle(datagen.SQLCopy),
+				datagen.SQLWriterSetTable("t")),
+			rows: 1,
+			expText: `COPY "t" (` + cols + ") FROM stdin;\n" +
+				"1\tit's\\tok\tTRUE\t2024-03-01 09:30:00+00:00\n" +
+				"\\.\n",
+		},
+		{
+			name: "type hints",
+			sw: datagen.NewSQLWriter(
+				datagen.SQLWriterSetFieldType("id", datagen.SQLTypeText),
+				datagen.SQLWriterSetFieldType("paid", datagen.SQLTypeBool)),
+			rows: 1,
+			expText: `INSERT INTO "orders" (` + cols + ") VALUES\n" +
+				"('1', 'it''s\tok', TRUE, '2024-03-01 09:30:00+00:00');\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		if err := tc.sw.Write(&buf, sqlTestRecord(), tc.rows); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if buf.String() != tc.expText {
+			t.Errorf("%s: bad output\nexpected: %q\n     got: %q",
+				tc.name, tc.expText, buf.String())
+		}
+	}
+}
+
+func TestSQLWriterNumeric(t *testing.T) {
+	usd := datagen.Countries["US"].Ccy()
+	r := datagen.NewRecord("r",
+		datagen.NewField("n", datagen.NewGen(
+			datagen.GenSetValue(1005),
+			datagen.GenSetStringMaker[int](
+				datagen.ConstMakeString[int]{Str: "1,005"}))),
+		datagen.NewField("f", datagen.NewGen(datagen.GenSetValue(2.5))),
+		datagen.NewField("m", datagen.NewGen(
+			datagen.GenSetValue(datagen.Money{Amt: 100512, Ccy: usd}))),
+	)
+	sw := datagen.NewSQLWriter(
+		datagen.SQLWriterSetFieldType("n", datagen.SQLTypeNumeric),
+		datagen.SQLWriterSetFieldType("f", datagen.SQLTypeNumeric),
+		datagen.SQLWriterSetFieldType("m", datagen.SQLTypeNumeric))
+
+	var buf bytes.Buffer
+	if err := sw.Write(&buf, r, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := "INSERT INTO \"r\" (\"n\", \"f\", \"m\") VALUES\n" +
+		"(1005, 2.5, 1005.12);\n"
+	if buf.String() != exp {
+		t.Errorf("bad output\nexpected: %q\n     got: %q", exp, buf.String())
+	}
+}
+
+// TestSQLWriterTypeMismatch checks that values which don't match their
+// type hints are reported as errors rather than being written unquoted
+func TestSQLWriterTypeMismatch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		g      datagen.Generator
+		sqlT   datagen.SQLType
+		expErr string
+	}{
+		{
+			name:   "formatted number string as numeric",
+			g:      datagen.NewConstGen[string]("1,005"),
+			sqlT:   datagen.SQLTypeNumeric,
+			expErr: "the value is not numeric",
+		},
+		{
+			name:   "string as numeric",
+			g:      datagen.NewGen(datagen.GenSetValue("1")),
+			sqlT:   datagen.SQLTypeNumeric,
+			expErr: "the value is not numeric",
+		},
+		{
+			name: "string as bool",
+			g: datagen.NewGen(
+				datagen.GenSetValue("yes'); DROP TABLE x; --")),
+			sqlT:   datagen.SQLTypeBool,
+			expErr: "the value is not a bool",
+		},
+		{
+			name: "weighted string as bool",
+			g: datagen.NewWStringGen(datagen.Random,
+				datagen.WeightedString{Str: "TRUE", Weight: 1}),
+			sqlT:   datagen.SQLTypeBool,
+			expErr: "the value is not a bool",
+		},
+	}
+
+	for _, tc := range testCases {
+		r := datagen.NewRecord("r", datagen.NewField("v", tc.g))
+		sw := datagen.NewSQLWriter(datagen.SQLWriterSetFieldType("v", tc.sqlT))
+
+		var buf bytes.Buffer
+
+		err := sw.Write(&buf, r, 1)
+		if err == nil {
+			t.Errorf("%s: an error was expected, the output was: %q",
+				tc.name, buf.String())
+
+			continue
+		}
+
+		if !strings.Contains(err.Error(), tc.expErr) {
+			t.Errorf("%s: the error should contain %q, got: %v",
+				tc.name, tc.expErr, err)
+		}
+
+		if strings.Contains(buf.String(), "DROP") {
+			t.Errorf("%s: the value was written: %q", tc.name, buf.String())
+		}
+	}
+}
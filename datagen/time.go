@@ -1,6 +1,8 @@
 package datagen
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"math/rand/v2"
 	"time"
@@ -8,6 +10,28 @@ import (
 
 const dfltTimeGenLayout = "2006/01/02 15:04:05.000"
 
+// ErrEndReached is the error reported by a TimeGen which has reached its
+// end time and is set to stop there.
+var ErrEndReached = errors.New("the end time has been reached")
+
+// ErrNoNextTime is the error reported by a TimeGen whose interval func has
+// found no next time (see TimeGenNoNextTime).
+var ErrNoNextTime = errors.New("there is no next time")
+
+// TimeGenEndAction determines what a TimeGen does when it reaches its end
+// time
+type TimeGenEndAction int
+
+// These constants give the actions a TimeGen can take on reaching its end
+// time.
+const (
+	// TimeGenEndStop leaves the time at its last value before the end and
+	// reports an ErrEndReached error
+	TimeGenEndStop TimeGenEndAction = iota
+	// TimeGenEndWrap sets the time back to its initial value
+	TimeGenEndWrap
+)
+
 // Time2Str records the details needed to convert from a time.Time to a
 // string.
 type Time2Str struct {
@@ -19,6 +43,12 @@ func (t2s Time2Str) ToStr(t time.Time) string {
 	return t.Format(t2s.format)
 }
 
+// MakeString returns a string representing the time. This allows a
+// Time2Str to be used as a StringMaker.
+func (t2s Time2Str) MakeString(t time.Time) string {
+	return t2s.ToStr(t)
+}
+
 // NewTime2Str returns a new Time2Str with the format value set
 func NewTime2Str(f string) *Time2Str {
 	return &Time2Str{format: f}
@@ -57,20 +87,54 @@ type TimeValSetGaussianInterval struct {
 	forceGT0 bool
 }
 
+// TimeValSetGaussianIntervalOptFunc is the type of an option-setting
+// function that will set a value in a TimeValSetGaussianInterval
+type TimeValSetGaussianIntervalOptFunc func(
+	tvs *TimeValSetGaussianInterval,
+) error
+
+// TimeValSetGaussianIntervalSetSeeder returns a TimeValSetGaussianInterval
+// Opt function which sets the random number generator to one taken from the
+// supplied Seeder. This allows the generated intervals to be reproduced.
+func TimeValSetGaussianIntervalSetSeeder(
+	s *Seeder,
+) TimeValSetGaussianIntervalOptFunc {
+	return func(tvs *TimeValSetGaussianInterval) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		tvs.r = s.NewRand()
+
+		return nil
+	}
+}
+
 // NewTimeValSetGaussianInterval constructs a new time value setter with a
-// random interval which follows a Gaussian (normal) distribution.
+// random interval which follows a Gaussian (normal) distribution. It will
+// panic if any of the option functions returns an error.
 func NewTimeValSetGaussianInterval(mean, sd float64,
 	units time.Duration,
 	forceGT0 bool,
+	opts ...TimeValSetGaussianIntervalOptFunc,
 ) *TimeValSetGaussianInterval {
 	tvs := &TimeValSetGaussianInterval{
-		r:        NewRand(),
 		mean:     mean,
 		sd:       sd,
 		units:    units,
 		forceGT0: forceGT0,
 	}
 
+	for _, o := range opts {
+		if err := o(tvs); err != nil {
+			panic(err)
+		}
+	}
+
+	if tvs.r == nil {
+		tvs.r = NewRand()
+	}
+
 	return tvs
 }
 
@@ -78,7 +142,7 @@ func NewTimeValSetGaussianInterval(mean, sd float64,
 // interval may be negative unless the forceGT0 flag is set. The interval
 // will always be in whole multiples of the units.
 func (tvs TimeValSetGaussianInterval) SetVal(t *time.Time) {
-	f := rand.NormFloat64() //nolint:gosec
+	f := tvs.r.NormFloat64()
 	if f <= 0 && tvs.forceGT0 {
 		if f == 0 {
 			f = 1.0
@@ -99,6 +163,12 @@ func (tvs TimeValSetGaussianInterval) SetVal(t *time.Time) {
 // interval between times
 type TimeGenIntervalF func(time.Time) time.Duration
 
+// TimeGenNoNextTime is the interval returned by a TimeGenIntervalF when
+// there is no next time. A TimeGen given this interval treats it as having
+// reached its end time or, if no end time has been set, stops, leaving the
+// time unchanged, and reports an ErrNoNextTime error.
+const TimeGenNoNextTime time.Duration = math.MaxInt64
+
 // TimeGenConstIntervalF returns an interval func which always returns the
 // supplied duration.
 func TimeGenConstIntervalF(d time.Duration) TimeGenIntervalF {
@@ -112,6 +182,14 @@ type TimeGen struct {
 	layout    string
 	value     time.Time
 	intervalF TimeGenIntervalF
+	cal       *Calendar
+	locF      func() *time.Location
+
+	initial time.Time
+	end     time.Time
+	atEnd   TimeGenEndAction
+	wraps   int
+	err     error
 }
 
 // TimeGenOptFunc is the type of an option-setting function that will set a
@@ -145,8 +223,80 @@ func TimeGenSetIntervalF(f TimeGenIntervalF) TimeGenOptFunc {
 	}
 }
 
+// TimeGenSetCalendar returns a TimeGen Opt function which sets a Calendar.
+// The initial time and each subsequent time are rolled forward to the next
+// time that the Calendar is open, so that only business times are given.
+func TimeGenSetCalendar(cal *Calendar) TimeGenOptFunc {
+	return func(tg *TimeGen) error {
+		if cal == nil {
+			return errors.New("a nil Calendar has been supplied")
+		}
+
+		tg.cal = cal
+
+		return nil
+	}
+}
+
+// TimeGenSetDisplayLocation returns a TimeGen Opt function which sets the
+// location in which the time is displayed and in which the value is given.
+// The time is generated in the location of the initial time; this allows it
+// to be rendered in another.
+func TimeGenSetDisplayLocation(loc *time.Location) TimeGenOptFunc {
+	return func(tg *TimeGen) error {
+		if loc == nil {
+			return errors.New("a nil location has been supplied")
+		}
+
+		tg.locF = func() *time.Location { return loc }
+
+		return nil
+	}
+}
+
+// TimeGenSetDisplayLocationGen returns a TimeGen Opt function which sets a
+// generator giving the location in which the time is displayed and in
+// which the value is given. The location is taken each time the time is
+// used so it can vary from row to row, for instance, being chosen by a
+// WeightedGen[*time.Location] or depending on another field such as the
+// row's country. The generator should be moved on by its own Record; a nil
+// location leaves the time in the location it was generated in.
+func TimeGenSetDisplayLocationGen(
+	lg TypedGenerator[*time.Location],
+) TimeGenOptFunc {
+	return func(tg *TimeGen) error {
+		if lg == nil {
+			return errors.New("a nil location generator has been supplied")
+		}
+
+		tg.locF = lg.Value
+
+		return nil
+	}
+}
+
+// TimeGenSetEnd returns a TimeGen Opt function which sets the end time and
+// what is done when it is reached. The times given are always before the
+// end time; when the next time would not be, the TimeGen either stops,
+// repeating the last time and reporting an error through the Err method,
+// or wraps around, starting again from the initial time. The number of
+// times it has wrapped around is given by the Wraps method.
+func TimeGenSetEnd(end time.Time, atEnd TimeGenEndAction) TimeGenOptFunc {
+	return func(tg *TimeGen) error {
+		if atEnd != TimeGenEndStop && atEnd != TimeGenEndWrap {
+			return fmt.Errorf("bad TimeGen end action: %d", atEnd)
+		}
+
+		tg.end = end
+		tg.atEnd = atEnd
+
+		return nil
+	}
+}
+
 // NewTimeGen creates a new TimeGen object. It will panic if any of the
-// option functions returns an error.
+// option functions returns an error or if an end time is set and the
+// initial time is not before it.
 func NewTimeGen(opts ...TimeGenOptFunc) *TimeGen {
 	tg := &TimeGen{
 		layout:    dfltTimeGenLayout,
@@ -160,20 +310,90 @@ func NewTimeGen(opts ...TimeGenOptFunc) *TimeGen {
 		}
 	}
 
+	if tg.cal != nil {
+		tg.value = tg.cal.NextOpen(tg.value)
+	}
+
+	if !tg.end.IsZero() && !tg.value.Before(tg.end) {
+		panic(fmt.Errorf("the initial time (%s) must be before the end (%s)",
+			tg.value, tg.end))
+	}
+
+	tg.initial = tg.value
+
 	return tg
 }
 
 // Generate generates a formatted time string
 func (tg TimeGen) Generate() string {
-	return tg.value.Format(tg.layout)
+	return tg.Value().Format(tg.layout)
 }
 
-// Value returns the current value as a time
+// Value returns the current value as a time, in the display location if
+// one has been set
 func (tg TimeGen) Value() time.Time {
+	if tg.locF != nil {
+		if loc := tg.locF(); loc != nil {
+			return tg.value.In(loc)
+		}
+	}
+
 	return tg.value
 }
 
-// Next moves the time on to its next value
+// Next moves the time on to its next value. If an end time has been set
+// and the next value would not be before it, or the interval func finds no
+// next time, then the time either stays unchanged or is set back to the
+// initial time (see TimeGenSetEnd). With no end time, a TimeGen whose
+// interval func finds no next time stops (see TimeGenNoNextTime).
 func (tg *TimeGen) Next() {
-	tg.value = tg.value.Add(tg.intervalF(tg.value))
+	if tg.err != nil {
+		return
+	}
+
+	ival := tg.intervalF(tg.value)
+
+	var next time.Time
+
+	switch {
+	case ival != TimeGenNoNextTime:
+		next = tg.value.Add(ival)
+
+		if tg.cal != nil {
+			next = tg.cal.NextOpen(next)
+		}
+	case tg.end.IsZero():
+		tg.err = fmt.Errorf("%w after %s", ErrNoNextTime, tg.value)
+
+		return
+	default:
+		next = tg.end
+	}
+
+	if !tg.end.IsZero() && !next.Before(tg.end) {
+		if tg.atEnd == TimeGenEndStop {
+			tg.err = fmt.Errorf("%w: the time after %s is not before %s",
+				ErrEndReached, tg.value, tg.end)
+
+			return
+		}
+
+		next = tg.initial
+		tg.wraps++
+	}
+
+	tg.value = next
+}
+
+// Err returns a non-nil error if the TimeGen has stopped at its end time
+// or because there is no next time. It implements the ErrReporter
+// interface.
+func (tg TimeGen) Err() error {
+	return tg.err
+}
+
+// Wraps returns the number of times that the TimeGen has reached its end
+// time and started again from the initial time
+func (tg TimeGen) Wraps() int {
+	return tg.wraps
 }
@@ -0,0 +1,181 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// maxThinningDraws is the number of candidate arrivals drawn in search of
+// the next arrival of a non-homogeneous Poisson process before giving up.
+// It is only reached if the rate is zero, or far below the maximum rate,
+// for a very long time.
+const maxThinningDraws = 1000000
+
+// timeIntervalOpts records the settings common to the random interval
+// functions
+type timeIntervalOpts struct {
+	r *rand.Rand
+}
+
+// TimeIntervalOptFunc is the type of an option-setting function that will
+// set a value used by one of the random TimeGenIntervalF constructors
+type TimeIntervalOptFunc func(tio *timeIntervalOpts) error
+
+// TimeIntervalSetSeeder returns a TimeIntervalOptFunc which sets the random
+// number generator to one taken from the supplied Seeder. This allows the
+// generated intervals to be reproduced.
+func TimeIntervalSetSeeder(s *Seeder) TimeIntervalOptFunc {
+	return func(tio *timeIntervalOpts) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		tio.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// newTimeIntervalOpts applies the options and returns the settings. It
+// will panic if any of the option functions returns an error.
+func newTimeIntervalOpts(opts ...TimeIntervalOptFunc) *timeIntervalOpts {
+	tio := &timeIntervalOpts{}
+
+	for _, o := range opts {
+		if err := o(tio); err != nil {
+			panic(err)
+		}
+	}
+
+	if tio.r == nil {
+		tio.r = NewRand()
+	}
+
+	return tio
+}
+
+// TimeGenPoissonIntervalF returns an interval func giving exponentially
+// distributed intervals with the given mean. This gives the arrival times
+// of a (homogeneous) Poisson process, such as independent events occurring
+// at a constant average rate. It will panic if the mean is not > 0 or if
+// any of the option functions returns an error.
+func TimeGenPoissonIntervalF(mean time.Duration,
+	opts ...TimeIntervalOptFunc,
+) TimeGenIntervalF {
+	if mean <= 0 {
+		panic(fmt.Errorf("the mean interval (%s) must be > 0", mean))
+	}
+
+	tio := newTimeIntervalOpts(opts...)
+
+	return func(_ time.Time) time.Duration {
+		return time.Duration(tio.r.ExpFloat64() * float64(mean))
+	}
+}
+
+// TimeGenNHPoissonIntervalF returns an interval func giving the arrival
+// times of a non-homogeneous Poisson process; one whose rate varies over
+// time. The rate function gives the rate, in events per the given
+// duration, at a given time. It must never exceed maxRate.
+//
+// The arrivals are generated by thinning (see Lewis and Shedler,
+// "Simulation of Nonhomogeneous Poisson Processes by Thinning"); candidate
+// arrivals are generated at the maximum rate and each is kept with a
+// probability of the ratio of the rate at that time to the maximum rate.
+// If no arrival is kept from a million candidates, as happens if the rate
+// is zero from some time onwards, the interval func gives up and returns
+// TimeGenNoNextTime so that a TimeGen using it stops cleanly.
+//
+// It will panic if the rate function is nil, if maxRate or per is not > 0
+// or if any of the option functions returns an error.
+func TimeGenNHPoissonIntervalF(rate func(time.Time) float64,
+	maxRate float64, per time.Duration,
+	opts ...TimeIntervalOptFunc,
+) TimeGenIntervalF {
+	if rate == nil {
+		panic(errors.New("a nil rate function has been supplied"))
+	}
+
+	if !(maxRate > 0) || math.IsInf(maxRate, 1) {
+		panic(fmt.Errorf("the maximum rate (%g) must be > 0 and finite",
+			maxRate))
+	}
+
+	if per <= 0 {
+		panic(fmt.Errorf("the rate duration (%s) must be > 0", per))
+	}
+
+	tio := newTimeIntervalOpts(opts...)
+	mean := float64(per) / maxRate
+
+	return func(t time.Time) time.Duration {
+		candidate := t
+
+		for range maxThinningDraws {
+			candidate = candidate.Add(
+				time.Duration(tio.r.ExpFloat64() * mean))
+
+			if tio.r.Float64()*maxRate < rate(candidate) {
+				return candidate.Sub(t)
+			}
+		}
+
+		return TimeGenNoNextTime
+	}
+}
+
+// TimeGenDiurnalIntervalF returns an interval func giving the arrival
+// times of a Poisson process whose rate varies by the hour of the day and
+// the day of the week, as for web traffic or transactions with busy and
+// quiet periods. The rate at a given time, in events per hour, is
+// perHour * hourly[hour] * daily[weekday], where the hour and weekday are
+// taken in the time's location and daily is indexed by time.Weekday (so
+// starting on Sunday). It will panic if perHour is not > 0, if any of the
+// factors is < 0, if all the hourly or all the daily factors are 0 or if
+// any of the option functions returns an error.
+func TimeGenDiurnalIntervalF(perHour float64,
+	hourly [hoursPerDay]float64, daily [daysPerWeek]float64,
+	opts ...TimeIntervalOptFunc,
+) TimeGenIntervalF {
+	if !(perHour > 0) {
+		panic(fmt.Errorf("the rate per hour (%g) must be > 0", perHour))
+	}
+
+	maxHourly, err := checkRateFactors("hourly", hourly[:])
+	if err != nil {
+		panic(err)
+	}
+
+	maxDaily, err := checkRateFactors("daily", daily[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return TimeGenNHPoissonIntervalF(
+		func(t time.Time) float64 {
+			return perHour * hourly[t.Hour()] * daily[t.Weekday()]
+		},
+		perHour*maxHourly*maxDaily, time.Hour, opts...)
+}
+
+// checkRateFactors returns the largest of the factors and an error if any
+// of the factors is < 0 or if they are all 0
+func checkRateFactors(name string, factors []float64) (float64, error) {
+	for i, f := range factors {
+		if !(f >= 0) {
+			return 0, fmt.Errorf("the %s rate factor[%d] (%g) must be >= 0",
+				name, i, f)
+		}
+	}
+
+	maxF := slices.Max(factors)
+	if maxF == 0 {
+		return 0, fmt.Errorf("the %s rate factors are all 0", name)
+	}
+
+	return maxF, nil
+}
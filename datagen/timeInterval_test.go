@@ -0,0 +1,138 @@
+package datagen_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// intervalSeed returns the seed option used by the interval tests
+func intervalSeed() datagen.TimeIntervalOptFunc {
+	return datagen.TimeIntervalSetSeeder(datagen.NewSeeder(42))
+}
+
+// arrivals returns the times given by n calls of the interval func
+func arrivals(f datagen.TimeGenIntervalF,
+	start time.Time, n int,
+) []time.Time {
+	t := start
+	times := make([]time.Time, 0, n)
+
+	for range n {
+		t = t.Add(f(t))
+		times = append(times, t)
+	}
+
+	return times
+}
+
+func TestTimeGenPoissonIntervalF(t *testing.T) {
+	const n = 100000
+
+	times := arrivals(
+		datagen.TimeGenPoissonIntervalF(time.Minute, intervalSeed()),
+		tsStart, n)
+
+	mean := times[n-1].Sub(tsStart) / n
+	if d := mean - time.Minute; d.Abs() > time.Second {
+		t.Errorf("expected a mean interval of about 1m, got %s", mean)
+	}
+}
+
+func TestTimeGenNHPoissonIntervalF(t *testing.T) {
+	// events only happen in the second half of each hour
+	rate := func(t time.Time) float64 {
+		if t.Minute() < 30 {
+			return 0
+		}
+
+		return 60
+	}
+
+	times := arrivals(
+		datagen.TimeGenNHPoissonIntervalF(rate, 60, time.Hour,
+			intervalSeed()),
+		tsStart, 10000)
+
+	for _, at := range times {
+		if at.Minute() < 30 {
+			t.Fatalf("an arrival at %s is when the rate is 0", at)
+		}
+	}
+
+	perHour := 10000 / times[len(times)-1].Sub(tsStart).Hours()
+	if math.Abs(perHour-30) > 1 {
+		t.Errorf("expected about 30 arrivals per hour, got %g", perHour)
+	}
+}
+
+// TestTimeGenNHPoissonIntervalFZeroRate checks that a rate which stays at
+// zero stops the TimeGen cleanly rather than searching forever
+func TestTimeGenNHPoissonIntervalFZeroRate(t *testing.T) {
+	rate := func(t time.Time) float64 {
+		if t.Before(tsStart.Add(time.Hour)) {
+			return 1
+		}
+
+		return 0
+	}
+	tg := datagen.NewTimeGen(
+		datagen.TimeGenSetInitialTime(tsStart),
+		datagen.TimeGenSetLayout(time.RFC3339),
+		datagen.TimeGenSetIntervalF(datagen.TimeGenNHPoissonIntervalF(
+			rate, 100, time.Hour, intervalSeed())))
+
+	var buf bytes.Buffer
+
+	err := datagen.NewCSVWriter(datagen.CSVWriterSetShowTitles(false)).
+		Write(&buf, datagen.NewRecord("r", datagen.NewField("at", tg)), 1000)
+	if !errors.Is(err, datagen.ErrNoNextTime) {
+		t.Errorf("expected an ErrNoNextTime error, got: %v", err)
+	}
+
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rows) == 0 || len(rows) >= 1000 {
+		t.Errorf("expected some, but not all, rows to be written, got %d",
+			len(rows))
+	}
+
+	for _, row := range rows {
+		if at, err := time.Parse(time.RFC3339, row); err != nil ||
+			!at.Before(tsStart.Add(time.Hour)) {
+			t.Errorf("bad row: %q", row)
+		}
+	}
+}
+
+func TestTimeGenDiurnalIntervalF(t *testing.T) {
+	var hourly [24]float64
+
+	hourly[9], hourly[10] = 1, 3
+
+	daily := [7]float64{0, 1, 1, 1, 1, 1, 0}
+	counts := map[int]int{}
+
+	for _, at := range arrivals(
+		datagen.TimeGenDiurnalIntervalF(100, hourly, daily, intervalSeed()),
+		tsStart, 10000) {
+		if at.Weekday() == time.Saturday || at.Weekday() == time.Sunday {
+			t.Fatalf("an arrival at %s is at the weekend", at)
+		}
+
+		counts[at.Hour()]++
+	}
+
+	if len(counts) != 2 {
+		t.Errorf("arrivals were only expected at 9 and 10, got: %v", counts)
+	}
+
+	if ratio := float64(counts[10]) / float64(counts[9]); math.Abs(ratio-3) > 0.3 {
+		t.Errorf("expected 3 times as many arrivals at 10 as at 9, got %g",
+			ratio)
+	}
+}
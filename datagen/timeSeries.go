@@ -0,0 +1,470 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+)
+
+// These are the labels given to anomalous points by a TimeSeriesLabel
+const (
+	AnomalySpike      = "spike"
+	AnomalyLevelShift = "levelShift"
+	AnomalyDropout    = "dropout"
+)
+
+// hoursPerDay and daysPerWeek give the sizes of the daily and weekly
+// profiles
+const (
+	hoursPerDay = 24
+	daysPerWeek = 7
+)
+
+// TimeSeriesF is the type of a function giving a component of a time series
+// at a given time. Functions are provided for the common components (trend,
+// seasonality and noise) but any function may be used.
+type TimeSeriesF func(time.Time) float64
+
+// NewLinearTrend returns a TimeSeriesF giving a linear trend which has the
+// given level at the origin time and changes by slope every per duration.
+// It will panic if per is not > 0.
+func NewLinearTrend(origin time.Time, level, slope float64,
+	per time.Duration,
+) TimeSeriesF {
+	if per <= 0 {
+		panic(fmt.Errorf("the trend slope duration (%s) must be > 0", per))
+	}
+
+	return func(t time.Time) float64 {
+		return level + slope*float64(t.Sub(origin))/float64(per)
+	}
+}
+
+// TrendPoint gives the level of a piecewise trend at a point in time
+type TrendPoint struct {
+	At  time.Time
+	Val float64
+}
+
+// NewPiecewiseTrend returns a TimeSeriesF giving a piecewise linear trend
+// passing through the points. Before the first point and after the last the
+// trend is flat. It will panic if no points are given or if the points are
+// not in increasing time order.
+func NewPiecewiseTrend(pts ...TrendPoint) TimeSeriesF {
+	if len(pts) == 0 {
+		panic(errors.New("no trend points have been given"))
+	}
+
+	for i := 1; i < len(pts); i++ {
+		if !pts[i].At.After(pts[i-1].At) {
+			panic(fmt.Errorf(
+				"the trend points must be in increasing time order:"+
+					" point %d (%s) is not after point %d (%s)",
+				i, pts[i].At, i-1, pts[i-1].At))
+		}
+	}
+
+	pts = append([]TrendPoint(nil), pts...)
+
+	return func(t time.Time) float64 {
+		i := sort.Search(len(pts), func(i int) bool {
+			return pts[i].At.After(t)
+		})
+
+		if i == 0 {
+			return pts[0].Val
+		}
+
+		if i == len(pts) {
+			return pts[len(pts)-1].Val
+		}
+
+		p0, p1 := pts[i-1], pts[i]
+		frac := float64(t.Sub(p0.At)) / float64(p1.At.Sub(p0.At))
+
+		return p0.Val + frac*(p1.Val-p0.Val)
+	}
+}
+
+// NewSineSeason returns a TimeSeriesF giving a sinusoidal seasonal
+// component with the given period and amplitude, peaking at the peak time
+// (and at every whole period before and after it). It will panic if the
+// period is not > 0.
+func NewSineSeason(period time.Duration, amplitude float64,
+	peak time.Time,
+) TimeSeriesF {
+	if period <= 0 {
+		panic(fmt.Errorf("the seasonal period (%s) must be > 0", period))
+	}
+
+	return func(t time.Time) float64 {
+		phase := float64(t.Sub(peak)) / float64(period)
+		return amplitude * math.Cos(2*math.Pi*phase) //nolint:mnd
+	}
+}
+
+// NewDailyProfile returns a TimeSeriesF giving a daily seasonal component.
+// The profile gives the value at the start of each hour of the day, in the
+// time's location, and the values between are interpolated.
+func NewDailyProfile(hourly [hoursPerDay]float64) TimeSeriesF {
+	return func(t time.Time) float64 {
+		h := t.Hour()
+		frac := float64(t.Sub(t.Truncate(time.Hour))) / float64(time.Hour)
+
+		return hourly[h] + frac*(hourly[(h+1)%hoursPerDay]-hourly[h])
+	}
+}
+
+// NewWeeklyProfile returns a TimeSeriesF giving a weekly seasonal
+// component. The profile gives the value for each day of the week, in the
+// time's location, indexed by time.Weekday (so starting on Sunday).
+func NewWeeklyProfile(daily [daysPerWeek]float64) TimeSeriesF {
+	return func(t time.Time) float64 {
+		return daily[t.Weekday()]
+	}
+}
+
+// NewNoise returns a TimeSeriesF giving noise from the ValSetter. The
+// value set is kept from one call to the next so ValSetters which evolve
+// the value, such as a ProcessValSetter, give correlated noise. It will
+// panic if the ValSetter is nil.
+func NewNoise(vs ValSetter[float64]) TimeSeriesF {
+	if vs == nil {
+		panic(errors.New("a nil value setter has been supplied"))
+	}
+
+	var x float64
+
+	return func(_ time.Time) float64 {
+		vs.SetVal(&x)
+		return x
+	}
+}
+
+// tsComponent records a component of the time series and whether it is
+// multiplied into the value or added to it
+type tsComponent struct {
+	f        TimeSeriesF
+	multiply bool
+}
+
+// tsLevelShift records a shift in the level of a time series between the
+// from time and the to time
+type tsLevelShift struct {
+	from, to time.Time
+	delta    float64
+}
+
+// TimeSeriesGen generates the values of a time series at the times given
+// by a timeline, typically a TimeGen. The value is built from the
+// components in the order they were given, each being added to or
+// multiplying the value so far, starting from zero. Anomalies (spikes,
+// level shifts and dropouts) can then be injected and a label showing
+// which points are anomalous is available through Label. A dropout makes
+// the value NULL. It implements the TypedGenerator and Nullable interfaces.
+//
+// The timeline is not moved on by the TimeSeriesGen, it should be moved on
+// by its own Record, typically being an earlier field of the same Record.
+// The value is calculated when it is first needed after the TimeSeriesGen
+// has been moved on, so it is always calculated at the timeline's current
+// time. A point whose value, label and NULL status are never read is
+// skipped: no value is calculated for it and the stateful components, the
+// anomalies and any dropout run are not moved on.
+type TimeSeriesGen struct {
+	timeline TypedGenerator[time.Time]
+	r        *rand.Rand
+	sm       StringMaker[float64]
+
+	components  []tsComponent
+	levelShifts []tsLevelShift
+	spikeProb   float64
+	spikeSize   float64
+	dropoutProb float64
+	dropoutLen  int
+
+	calculated bool
+	value      float64
+	dropoutRem int
+	labels     []string
+}
+
+// TimeSeriesGenOptFunc is the type of an option-setting function that will
+// set a value in a TimeSeriesGen
+type TimeSeriesGenOptFunc func(ts *TimeSeriesGen) error
+
+// TimeSeriesGenSetSeeder returns a TimeSeriesGen Opt function which sets
+// the random number generator, used to inject the anomalies, to one taken
+// from the supplied Seeder.
+func TimeSeriesGenSetSeeder(s *Seeder) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		ts.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// TimeSeriesGenSetStringMaker returns a TimeSeriesGen Opt function which
+// sets the StringMaker used to generate the string form of the value. The
+// default gives the Go string representation of the value.
+func TimeSeriesGenSetStringMaker(sm StringMaker[float64]) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if sm == nil {
+			return errors.New("a nil string maker has been supplied")
+		}
+
+		ts.sm = sm
+
+		return nil
+	}
+}
+
+// TimeSeriesGenAdd returns a TimeSeriesGen Opt function which adds a
+// component to be added to the value
+func TimeSeriesGenAdd(f TimeSeriesF) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if f == nil {
+			return errors.New("a nil time series component has been supplied")
+		}
+
+		ts.components = append(ts.components, tsComponent{f: f})
+
+		return nil
+	}
+}
+
+// TimeSeriesGenMultiply returns a TimeSeriesGen Opt function which adds a
+// component to multiply the value by. For instance, a seasonal factor
+// varying around 1.
+func TimeSeriesGenMultiply(f TimeSeriesF) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if f == nil {
+			return errors.New("a nil time series component has been supplied")
+		}
+
+		ts.components = append(ts.components,
+			tsComponent{f: f, multiply: true})
+
+		return nil
+	}
+}
+
+// TimeSeriesGenSetSpikes returns a TimeSeriesGen Opt function which makes
+// each point a spike with probability p; the size is added to the value of
+// a spike.
+func TimeSeriesGenSetSpikes(p, size float64) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if err := checkProb(p); err != nil {
+			return err
+		}
+
+		ts.spikeProb = p
+		ts.spikeSize = size
+
+		return nil
+	}
+}
+
+// TimeSeriesGenAddLevelShift returns a TimeSeriesGen Opt function which
+// adds delta to the value of every point at or after the from time and
+// before the to time. If the to time is the zero time the shift is
+// permanent.
+func TimeSeriesGenAddLevelShift(from, to time.Time,
+	delta float64,
+) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if !to.IsZero() && !to.After(from) {
+			return fmt.Errorf(
+				"the level shift end (%s) must be after its start (%s)",
+				to, from)
+		}
+
+		ts.levelShifts = append(ts.levelShifts,
+			tsLevelShift{from: from, to: to, delta: delta})
+
+		return nil
+	}
+}
+
+// TimeSeriesGenSetDropouts returns a TimeSeriesGen Opt function which
+// starts a run of runLen missing (NULL) values with probability p.
+func TimeSeriesGenSetDropouts(p float64, runLen int) TimeSeriesGenOptFunc {
+	return func(ts *TimeSeriesGen) error {
+		if err := checkProb(p); err != nil {
+			return err
+		}
+
+		if runLen <= 0 {
+			return fmt.Errorf("the dropout length (%d) must be > 0", runLen)
+		}
+
+		ts.dropoutProb = p
+		ts.dropoutLen = runLen
+
+		return nil
+	}
+}
+
+// NewTimeSeriesGen creates a new TimeSeriesGen giving values at the times
+// given by the timeline. It will panic if the timeline is nil or if any of
+// the option functions returns an error.
+func NewTimeSeriesGen(timeline TypedGenerator[time.Time],
+	opts ...TimeSeriesGenOptFunc,
+) *TimeSeriesGen {
+	if timeline == nil {
+		panic(errors.New("a nil timeline has been supplied"))
+	}
+
+	ts := &TimeSeriesGen{
+		timeline: timeline,
+		sm:       dfltGenImpl[float64]{},
+	}
+
+	for _, o := range opts {
+		if err := o(ts); err != nil {
+			panic(err)
+		}
+	}
+
+	if ts.r == nil {
+		ts.r = NewRand()
+	}
+
+	return ts
+}
+
+// calculate sets the value and the labels for the current time if they
+// have not been set already
+func (ts *TimeSeriesGen) calculate() {
+	if ts.calculated {
+		return
+	}
+
+	ts.calculated = true
+	ts.labels = ts.labels[:0]
+
+	t := ts.timeline.Value()
+	v := 0.0
+
+	for _, c := range ts.components {
+		if c.multiply {
+			v *= c.f(t)
+		} else {
+			v += c.f(t)
+		}
+	}
+
+	for _, ls := range ts.levelShifts {
+		if !t.Before(ls.from) && (ls.to.IsZero() || t.Before(ls.to)) {
+			v += ls.delta
+
+			ts.addLabel(AnomalyLevelShift)
+		}
+	}
+
+	if ts.spikeProb > 0 && ts.r.Float64() < ts.spikeProb {
+		v += ts.spikeSize
+
+		ts.addLabel(AnomalySpike)
+	}
+
+	if ts.dropoutRem > 0 {
+		ts.dropoutRem--
+	}
+
+	if ts.dropoutRem == 0 && ts.dropoutProb > 0 &&
+		ts.r.Float64() < ts.dropoutProb {
+		ts.dropoutRem = ts.dropoutLen
+	}
+
+	if ts.dropoutRem > 0 {
+		// the value is missing so any other anomalies are irrelevant
+		ts.labels = append(ts.labels[:0], AnomalyDropout)
+	}
+
+	ts.value = v
+}
+
+// addLabel adds the label if it is not already present
+func (ts *TimeSeriesGen) addLabel(l string) {
+	for _, existing := range ts.labels {
+		if existing == l {
+			return
+		}
+	}
+
+	ts.labels = append(ts.labels, l)
+}
+
+// Value returns the value of the series at the current time. The zero
+// value is returned if the value is missing (see IsNull).
+func (ts *TimeSeriesGen) Value() float64 {
+	ts.calculate()
+
+	if ts.dropoutRem > 0 {
+		return 0
+	}
+
+	return ts.value
+}
+
+// Generate returns the string form of the value or the empty string if the
+// value is missing
+func (ts *TimeSeriesGen) Generate() string {
+	if ts.IsNull() {
+		return ""
+	}
+
+	return ts.sm.MakeString(ts.Value())
+}
+
+// IsNull returns true if the value is missing due to a dropout
+func (ts *TimeSeriesGen) IsNull() bool {
+	ts.calculate()
+
+	return ts.dropoutRem > 0
+}
+
+// Next moves the series on so that the value will be calculated afresh
+// from the timeline's time when it is next needed. Nothing is calculated
+// for a point which has not been read (see TimeSeriesGen).
+func (ts *TimeSeriesGen) Next() {
+	ts.calculated = false
+}
+
+// Label returns a TypedGenerator giving the labels for the current point;
+// the empty string for a normal point or the anomaly labels (AnomalySpike,
+// AnomalyLevelShift and AnomalyDropout) separated by '+'. It can be used as
+// a field in the same Record as the TimeSeriesGen.
+func (ts *TimeSeriesGen) Label() *TimeSeriesLabel {
+	return &TimeSeriesLabel{ts: ts}
+}
+
+// TimeSeriesLabel gives the anomaly labels for the points of a
+// TimeSeriesGen. It implements the TypedGenerator interface.
+type TimeSeriesLabel struct {
+	ts *TimeSeriesGen
+}
+
+// Value returns the labels for the current point
+func (tl TimeSeriesLabel) Value() string {
+	tl.ts.calculate()
+
+	return strings.Join(tl.ts.labels, "+")
+}
+
+// Generate returns the labels for the current point
+func (tl TimeSeriesLabel) Generate() string {
+	return tl.Value()
+}
+
+// Next does nothing; the TimeSeriesGen is moved on by its own Record
+func (tl TimeSeriesLabel) Next() {
+}
@@ -0,0 +1,128 @@
+package datagen_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+var tsStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// hourlyTimeline returns a TimeGen giving a time every hour from tsStart
+func hourlyTimeline() *datagen.TimeGen {
+	return datagen.NewTimeGen(
+		datagen.TimeGenSetInitialTime(tsStart),
+		datagen.TimeGenSetIntervalF(datagen.TimeGenConstIntervalF(time.Hour)))
+}
+
+func TestTimeSeriesGenComponents(t *testing.T) {
+	tl := hourlyTimeline()
+	ts := datagen.NewTimeSeriesGen(tl,
+		datagen.TimeSeriesGenAdd(
+			datagen.NewLinearTrend(tsStart, 100, 10, time.Hour)),
+		datagen.TimeSeriesGenMultiply(func(t time.Time) float64 {
+			if t.Hour()%2 == 0 {
+				return 1
+			}
+
+			return 2
+		}),
+		datagen.TimeSeriesGenAddLevelShift(
+			tsStart.Add(2*time.Hour), tsStart.Add(3*time.Hour), -5))
+	label := ts.Label()
+
+	var (
+		vals   []float64
+		labels []string
+	)
+
+	for range 4 {
+		vals = append(vals, ts.Value())
+		labels = append(labels, label.Value())
+		tl.Next()
+		ts.Next()
+	}
+
+	expVals := []float64{100, 220, 115, 260}
+	expLabels := []string{"", "", datagen.AnomalyLevelShift, ""}
+
+	for i := range expVals {
+		if vals[i] != expVals[i] || labels[i] != expLabels[i] {
+			t.Errorf("point %d: expected %g (%q), got %g (%q)",
+				i, expVals[i], expLabels[i], vals[i], labels[i])
+		}
+	}
+}
+
+// TestTimeSeriesGenUnreadPoints checks that moving the series on does not
+// calculate a point that has not been read, which would evaluate the
+// components at the timeline's next time and move the stateful components
+// on an extra step
+func TestTimeSeriesGenUnreadPoints(t *testing.T) {
+	var evaluated []time.Time
+
+	tl := hourlyTimeline()
+	ts := datagen.NewTimeSeriesGen(tl,
+		datagen.TimeSeriesGenAdd(func(t time.Time) float64 {
+			evaluated = append(evaluated, t)
+			return float64(t.Sub(tsStart) / time.Hour)
+		}))
+
+	for range 3 {
+		tl.Next()
+		ts.Next()
+	}
+
+	if len(evaluated) != 0 {
+		t.Errorf("unread points were calculated at: %v", evaluated)
+	}
+
+	if v := ts.Value(); v != 3 {
+		t.Errorf("expected the value at the fourth point to be 3, got %g", v)
+	}
+
+	ts.Value()
+
+	if len(evaluated) != 1 || !evaluated[0].Equal(tsStart.Add(3*time.Hour)) {
+		t.Errorf("expected a single calculation at 03:00, got: %v", evaluated)
+	}
+}
+
+func TestTimeSeriesGenDropouts(t *testing.T) {
+	tl := hourlyTimeline()
+	ts := datagen.NewTimeSeriesGen(tl,
+		datagen.TimeSeriesGenSetSeeder(datagen.NewSeeder(42)),
+		datagen.TimeSeriesGenAdd(func(time.Time) float64 { return 1 }),
+		datagen.TimeSeriesGenSetDropouts(0.05, 3))
+	r := datagen.NewRecord("r",
+		datagen.NewField("when", tl),
+		datagen.NewField("val", ts),
+		datagen.NewField("label", ts.Label()))
+
+	var buf bytes.Buffer
+	if err := datagen.NewCSVWriter().Write(&buf, r, 2000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run := 0
+
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n")[1:] {
+		parts := strings.Split(l, ",")
+
+		switch {
+		case parts[1] == "" && parts[2] == datagen.AnomalyDropout:
+			run++
+		case parts[1] == "1" && parts[2] == "":
+			if run%3 != 0 {
+				t.Errorf("a dropout run of %d is not a multiple of 3", run)
+			}
+
+			run = 0
+		default:
+			t.Errorf("bad row: %q", l)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// TimeWindowValSetter implements a ValSetter that will set the passed value
+// to a random time between a start and an end time. By default the times
+// are uniformly distributed but a distribution can be given (see
+// TimeWindowValSetterSetDist). The times can be truncated to a granularity,
+// such as whole minutes, or to dates.
+type TimeWindowValSetter struct {
+	r     *rand.Rand
+	start time.Time
+	end   time.Time
+
+	dist ValSetter[float64]
+	frac float64
+
+	granularity time.Duration
+	dateOnly    bool
+}
+
+// TimeWindowValSetterOptFunc is the type of an option-setting function
+// that will set a value in a TimeWindowValSetter
+type TimeWindowValSetterOptFunc func(vs *TimeWindowValSetter) error
+
+// TimeWindowValSetterSetSeeder returns a TimeWindowValSetter Opt function
+// which sets the random number generator to one taken from the supplied
+// Seeder. This allows the generated times to be reproduced.
+func TimeWindowValSetterSetSeeder(s *Seeder) TimeWindowValSetterOptFunc {
+	return func(vs *TimeWindowValSetter) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		vs.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// TimeWindowValSetterSetDist returns a TimeWindowValSetter Opt function
+// which sets the distribution of the times. The ValSetter gives the
+// position of the time in the window as a fraction between 0 (the start)
+// and 1 (the end); values outside this range are moved to the nearest end.
+// A bounded ValSetter should be used, such as a BetaValSetter or a
+// TriangularValSetter from 0 to 1, or a ProcessValSetter with bounds of 0
+// and 1 to give times that wander through the window. The ValSetter is
+// passed the previous value each time, starting from 0.
+func TimeWindowValSetterSetDist(
+	dist ValSetter[float64],
+) TimeWindowValSetterOptFunc {
+	return func(vs *TimeWindowValSetter) error {
+		if dist == nil {
+			return errors.New("a nil distribution has been supplied")
+		}
+
+		vs.dist = dist
+
+		return nil
+	}
+}
+
+// TimeWindowValSetterSetGranularity returns a TimeWindowValSetter Opt
+// function which sets the granularity of the times; they are truncated to
+// a whole multiple of the duration since the zero time (see
+// time.Time.Truncate) so, for instance, truncating to hours gives whole
+// hours in UTC, which are not whole local hours in every location. This
+// replaces any previous granularity, including that of
+// TimeWindowValSetterSetDateOnly.
+func TimeWindowValSetterSetGranularity(
+	d time.Duration,
+) TimeWindowValSetterOptFunc {
+	return func(vs *TimeWindowValSetter) error {
+		if d <= 0 {
+			return fmt.Errorf("the granularity (%s) must be > 0", d)
+		}
+
+		vs.granularity = d
+		vs.dateOnly = false
+
+		return nil
+	}
+}
+
+// TimeWindowValSetterSetDateOnly returns a TimeWindowValSetter Opt function
+// which truncates the times to midnight at the start of the day, in the
+// location of the start time. This replaces any previous granularity.
+func TimeWindowValSetterSetDateOnly() TimeWindowValSetterOptFunc {
+	return func(vs *TimeWindowValSetter) error {
+		vs.granularity = 0
+		vs.dateOnly = true
+
+		return nil
+	}
+}
+
+// NewTimeWindowValSetter creates and returns a TimeWindowValSetter giving
+// times from the start time up to, but not including, the end time, in the
+// location of the start time. If a granularity is set the times are the
+// whole units from the first at or after the start time to the last
+// before the end time and each is equally likely, except that with
+// TimeWindowValSetterSetDateOnly days are weighted by their length, which
+// changes when the clocks change. It will panic if the start is not before
+// the end, if there is no whole unit of the granularity between them or if
+// any of the option functions returns an error.
+func NewTimeWindowValSetter(start, end time.Time,
+	opts ...TimeWindowValSetterOptFunc,
+) *TimeWindowValSetter {
+	if !start.Before(end) {
+		panic(fmt.Errorf("the start (%s) must be before the end (%s)",
+			start, end))
+	}
+
+	vs := &TimeWindowValSetter{start: start, end: end}
+
+	for _, o := range opts {
+		if err := o(vs); err != nil {
+			panic(err)
+		}
+	}
+
+	if first := vs.truncate(start); first.Before(start) {
+		vs.start = vs.nextUnit(first)
+	}
+
+	if !vs.start.Before(end) {
+		panic(fmt.Errorf("there is no whole unit of time between %s and %s",
+			start, end))
+	}
+
+	if vs.dateOnly || vs.granularity > 0 {
+		vs.end = vs.nextUnit(vs.truncate(end.Add(-1)))
+	}
+
+	if vs.r == nil {
+		vs.r = NewRand()
+	}
+
+	return vs
+}
+
+// truncate returns the time truncated to the granularity
+func (vs TimeWindowValSetter) truncate(t time.Time) time.Time {
+	switch {
+	case vs.dateOnly:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0,
+			t.Location())
+	case vs.granularity > 0:
+		return t.Truncate(vs.granularity)
+	}
+
+	return t
+}
+
+// nextUnit returns the start of the unit of the granularity following the
+// unit starting at t
+func (vs TimeWindowValSetter) nextUnit(t time.Time) time.Time {
+	if vs.dateOnly {
+		return t.AddDate(0, 0, 1)
+	}
+
+	return t.Add(vs.granularity)
+}
+
+// SetVal sets the time to a random time in the window
+func (vs *TimeWindowValSetter) SetVal(t *time.Time) {
+	span := vs.end.Sub(vs.start)
+
+	var offset time.Duration
+
+	if vs.dist == nil {
+		offset = time.Duration(vs.r.Int64N(int64(span)))
+	} else {
+		vs.dist.SetVal(&vs.frac)
+
+		offset = time.Duration(
+			min(max(vs.frac, 0), 1) * float64(span))
+		if offset >= span {
+			offset = span - 1
+		}
+	}
+
+	*t = vs.truncate(vs.start.Add(offset))
+}
+
+// NewTimeWindowGen returns a generator of random times in the window given
+// by the TimeWindowValSetter. The initial value is set from the
+// TimeWindowValSetter and the times are displayed using the default
+// TimeGen layout; the options are applied afterwards so they can be used
+// to override these. For instance, GenSetStringMaker(NewTime2Str(layout))
+// will set the layout. It will panic if the TimeWindowValSetter is nil or
+// if any of the option functions returns an error.
+func NewTimeWindowGen(vs *TimeWindowValSetter,
+	opts ...GenOptFunc[time.Time],
+) *Gen[time.Time] {
+	if vs == nil {
+		panic(errors.New("a nil TimeWindowValSetter has been supplied"))
+	}
+
+	var t time.Time
+
+	vs.SetVal(&t)
+
+	return NewGen(append([]GenOptFunc[time.Time]{
+		GenSetValue(t),
+		GenSetValSetter[time.Time](vs),
+		GenSetStringMaker[time.Time](NewTime2Str(dfltTimeGenLayout)),
+	}, opts...)...)
+}
@@ -0,0 +1,182 @@
+package datagen_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// windowSeed returns the seed option used by the time window tests
+func windowSeed() datagen.TimeWindowValSetterOptFunc {
+	return datagen.TimeWindowValSetterSetSeeder(datagen.NewSeeder(42))
+}
+
+func TestTimeWindowValSetter(t *testing.T) {
+	start, end := calTime(1, 9, 30), calTime(3, 17, 0)
+
+	testCases := []struct {
+		name  string
+		opts  []datagen.TimeWindowValSetterOptFunc
+		first time.Time
+		last  time.Time
+		ok    func(time.Time) bool
+	}{
+		{
+			name:  "uniform",
+			first: start,
+			last:  end.Add(-1),
+			ok:    func(time.Time) bool { return true },
+		},
+		{
+			name: "whole hours",
+			opts: []datagen.TimeWindowValSetterOptFunc{
+				datagen.TimeWindowValSetterSetGranularity(time.Hour),
+			},
+			first: calTime(1, 10, 0),
+			last:  calTime(3, 16, 0),
+			ok: func(tm time.Time) bool {
+				return tm.Minute() == 0 && tm.Second() == 0 &&
+					tm.Nanosecond() == 0
+			},
+		},
+		{
+			name: "dates",
+			opts: []datagen.TimeWindowValSetterOptFunc{
+				datagen.TimeWindowValSetterSetDateOnly(),
+			},
+			first: calTime(2, 0, 0),
+			last:  calTime(3, 0, 0),
+			ok: func(tm time.Time) bool {
+				return tm.Hour() == 0 && tm.Minute() == 0 &&
+					tm.Second() == 0 && tm.Nanosecond() == 0
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		vs := datagen.NewTimeWindowValSetter(start, end,
+			append(tc.opts, windowSeed())...)
+		seen := map[time.Time]bool{}
+
+		var tm time.Time
+
+		for range 10000 {
+			vs.SetVal(&tm)
+
+			if tm.Before(tc.first) || tm.After(tc.last) || !tc.ok(tm) {
+				t.Fatalf("%s: %s is not a time from %s to %s",
+					tc.name, tm, tc.first, tc.last)
+			}
+
+			seen[tm] = true
+		}
+
+		if tc.opts != nil && (!seen[tc.first] || !seen[tc.last]) {
+			t.Errorf("%s: expected both %s and %s to be given",
+				tc.name, tc.first, tc.last)
+		}
+	}
+}
+
+func TestTimeWindowValSetterDist(t *testing.T) {
+	start := calTime(1, 0, 0)
+	vs := datagen.NewTimeWindowValSetter(start, calTime(2, 0, 0),
+		datagen.TimeWindowValSetterSetDist(
+			datagen.NewTriangularValSetter[float64](0, 0.75, 1,
+				distSeed[float64]())))
+
+	const n = 10000
+
+	var (
+		tm  time.Time
+		sum time.Duration
+	)
+
+	for range n {
+		vs.SetVal(&tm)
+		sum += tm.Sub(start)
+	}
+
+	// the mean of a triangular distribution is (min + mode + max) / 3
+	mean := sum / n
+	if d := mean - 14*time.Hour; d.Abs() > 15*time.Minute {
+		t.Errorf("expected a mean offset of about 14h, got %s", mean)
+	}
+}
+
+func TestNewTimeWindowGen(t *testing.T) {
+	g := datagen.NewTimeWindowGen(
+		datagen.NewTimeWindowValSetter(calTime(1, 0, 0), calTime(8, 0, 0),
+			windowSeed(), datagen.TimeWindowValSetterSetDateOnly()),
+		datagen.GenSetStringMaker[time.Time](
+			datagen.NewTime2Str(time.DateOnly)))
+	seen := map[string]bool{}
+
+	for range 100 {
+		s := g.Generate()
+		if s < "2024-01-01" || s > "2024-01-07" {
+			t.Errorf("%q is not a date in the first week of January", s)
+		}
+
+		seen[s] = true
+
+		g.Next()
+	}
+
+	if len(seen) != 7 {
+		t.Errorf("expected every day of the week to be given, got: %v", seen)
+	}
+}
+
+func TestNewTimeWindowValSetterBadArgs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		start  time.Time
+		end    time.Time
+		opts   []datagen.TimeWindowValSetterOptFunc
+		expErr string
+	}{
+		{
+			name:   "start after end",
+			start:  calTime(2, 0, 0),
+			end:    calTime(1, 0, 0),
+			expErr: "must be before the end",
+		},
+		{
+			name:  "no whole unit",
+			start: calTime(1, 10, 1),
+			end:   calTime(1, 10, 59),
+			opts: []datagen.TimeWindowValSetterOptFunc{
+				datagen.TimeWindowValSetterSetGranularity(time.Hour),
+			},
+			expErr: "there is no whole unit of time",
+		},
+		{
+			name:  "bad granularity",
+			start: calTime(1, 0, 0),
+			end:   calTime(2, 0, 0),
+			opts: []datagen.TimeWindowValSetterOptFunc{
+				datagen.TimeWindowValSetterSetGranularity(0),
+			},
+			expErr: "the granularity (0s) must be > 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				p := recover()
+				err, ok := p.(error)
+
+				if !ok || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("%s: a panic containing %q was expected, got: %v",
+						tc.name, tc.expErr, p)
+				}
+			}()
+
+			datagen.NewTimeWindowValSetter(tc.start, tc.end, tc.opts...)
+		}()
+	}
+}
@@ -0,0 +1,183 @@
+package datagen_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// endTimeline returns a TimeGen giving a time every hour from tsStart up
+// to the end time
+func endTimeline(end time.Time,
+	atEnd datagen.TimeGenEndAction,
+) *datagen.TimeGen {
+	return datagen.NewTimeGen(
+		datagen.TimeGenSetInitialTime(tsStart),
+		datagen.TimeGenSetIntervalF(datagen.TimeGenConstIntervalF(time.Hour)),
+		datagen.TimeGenSetEnd(end, atEnd))
+}
+
+func TestTimeGenEndStop(t *testing.T) {
+	tg := endTimeline(tsStart.Add(150*time.Minute), datagen.TimeGenEndStop)
+
+	var got []int
+
+	for range 5 {
+		got = append(got, tg.Value().Hour())
+		tg.Next()
+	}
+
+	if exp := []int{0, 1, 2, 2, 2}; !slices.Equal(got, exp) {
+		t.Errorf("expected hours %v, got %v", exp, got)
+	}
+
+	if err := tg.Err(); !errors.Is(err, datagen.ErrEndReached) {
+		t.Errorf("expected an ErrEndReached error, got: %v", err)
+	}
+
+	if tg.Wraps() != 0 {
+		t.Errorf("expected no wraps, got %d", tg.Wraps())
+	}
+}
+
+func TestTimeGenEndWrap(t *testing.T) {
+	tg := endTimeline(tsStart.Add(3*time.Hour), datagen.TimeGenEndWrap)
+
+	var got []int
+
+	for range 7 {
+		got = append(got, tg.Value().Hour())
+		tg.Next()
+	}
+
+	if exp := []int{0, 1, 2, 0, 1, 2, 0}; !slices.Equal(got, exp) {
+		t.Errorf("expected hours %v, got %v", exp, got)
+	}
+
+	if tg.Wraps() != 2 {
+		t.Errorf("expected 2 wraps, got %d", tg.Wraps())
+	}
+
+	if err := tg.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestTimeGenEndWriter checks that a writer stops with the error when a
+// TimeGen reaches its end
+func TestTimeGenEndWriter(t *testing.T) {
+	r := datagen.NewRecord("r", datagen.NewField("when",
+		endTimeline(tsStart.Add(3*time.Hour), datagen.TimeGenEndStop)))
+
+	var buf strings.Builder
+
+	err := datagen.NewCSVWriter().Write(&buf, r, 10)
+	if !errors.Is(err, datagen.ErrEndReached) {
+		t.Errorf("expected an ErrEndReached error, got: %v", err)
+	}
+}
+
+// TestTimeGenNoNextTime checks that an interval func finding no next time
+// is treated as reaching the end time
+func TestTimeGenNoNextTime(t *testing.T) {
+	ivalF := func(t time.Time) time.Duration {
+		if t.Before(tsStart.Add(time.Hour)) {
+			return time.Hour
+		}
+
+		return datagen.TimeGenNoNextTime
+	}
+
+	testCases := []struct {
+		name     string
+		opts     []datagen.TimeGenOptFunc
+		expHours []int
+		expErr   error
+	}{
+		{
+			name:     "no end",
+			expHours: []int{0, 1, 1, 1},
+			expErr:   datagen.ErrNoNextTime,
+		},
+		{
+			name: "stop",
+			opts: []datagen.TimeGenOptFunc{
+				datagen.TimeGenSetEnd(tsStart.Add(24*time.Hour),
+					datagen.TimeGenEndStop),
+			},
+			expHours: []int{0, 1, 1, 1},
+			expErr:   datagen.ErrEndReached,
+		},
+		{
+			name: "wrap",
+			opts: []datagen.TimeGenOptFunc{
+				datagen.TimeGenSetEnd(tsStart.Add(24*time.Hour),
+					datagen.TimeGenEndWrap),
+			},
+			expHours: []int{0, 1, 0, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tg := datagen.NewTimeGen(append([]datagen.TimeGenOptFunc{
+			datagen.TimeGenSetInitialTime(tsStart),
+			datagen.TimeGenSetIntervalF(ivalF),
+		}, tc.opts...)...)
+
+		var got []int
+
+		for range 4 {
+			got = append(got, tg.Value().Hour())
+			tg.Next()
+		}
+
+		if !slices.Equal(got, tc.expHours) {
+			t.Errorf("%s: expected hours %v, got %v",
+				tc.name, tc.expHours, got)
+		}
+
+		if err := tg.Err(); !errors.Is(err, tc.expErr) {
+			t.Errorf("%s: expected error %v, got: %v", tc.name, tc.expErr, err)
+		}
+	}
+}
+
+func TestNewTimeGenBadEnd(t *testing.T) {
+	testCases := []struct {
+		name   string
+		opt    datagen.TimeGenOptFunc
+		expErr string
+	}{
+		{
+			name:   "end before the start",
+			opt:    datagen.TimeGenSetEnd(tsStart, datagen.TimeGenEndStop),
+			expErr: "must be before the end",
+		},
+		{
+			name: "bad action",
+			opt: datagen.TimeGenSetEnd(tsStart.Add(time.Hour),
+				datagen.TimeGenEndAction(99)),
+			expErr: "bad TimeGen end action: 99",
+		},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				p := recover()
+				err, ok := p.(error)
+
+				if !ok || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("%s: a panic containing %q was expected, got: %v",
+						tc.name, tc.expErr, p)
+				}
+			}()
+
+			datagen.NewTimeGen(datagen.TimeGenSetInitialTime(tsStart), tc.opt)
+		}()
+	}
+}
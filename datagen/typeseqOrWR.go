@@ -6,9 +6,18 @@ type seqOrRandType int
 
 // These constants represent the control over whether values are generated
 // sequentially or randomly.
+//
+// Shuffled values are generated in a random order but each value is used
+// exactly once per cycle (in proportion to its weight where the values are
+// weighted) after which the values are reshuffled.
+//
+// NoReplacement values are drawn at random without replacement, as with
+// Shuffled, but once every value has been drawn no more are available.
 const (
 	Random seqOrRandType = iota
 	Sequential
+	Shuffled
+	NoReplacement
 )
 
 // IsValid is a method on the seqOrRandType type that can be used
@@ -20,7 +29,7 @@ func (v seqOrRandType) IsValid() bool {
 		return false
 	}
 
-	if v > Sequential {
+	if v > NoReplacement {
 		return false
 	}
 
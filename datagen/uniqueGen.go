@@ -0,0 +1,211 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const dfltUniqueMaxRetries = 1000
+
+// ErrNoUniqueValue is the error reported by a UniqueGen when it cannot find
+// a value that has not already been generated.
+var ErrNoUniqueValue = errors.New("no unique value could be generated")
+
+// seenSet is the interface describing how a UniqueGen records the values it
+// has generated. The add method records the value and returns false if it
+// has (or may have) been recorded already.
+type seenSet[T comparable] interface {
+	add(v T) bool
+}
+
+// mapSeenSet records every value in a map. It never reports a new value as
+// having been seen but its size grows with the number of values.
+type mapSeenSet[T comparable] map[T]struct{}
+
+// add records the value and returns false if it has already been recorded
+func (s mapSeenSet[T]) add(v T) bool {
+	if _, ok := s[v]; ok {
+		return false
+	}
+
+	s[v] = struct{}{}
+
+	return true
+}
+
+// bloomSeenSet records the values in a Bloom filter. Its size is fixed but
+// it will occasionally report a new value as having been seen already. The
+// values are hashed through their string representation (as given by the
+// %v format) so that the results are reproducible.
+type bloomSeenSet[T comparable] struct {
+	bits   []uint64
+	nBits  uint64
+	hashes int
+}
+
+// newBloomSeenSet returns a Bloom filter sized to hold n values with the
+// given false positive rate.
+func newBloomSeenSet[T comparable](n int, fpRate float64) *bloomSeenSet[T] {
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := max(1, int(math.Round(m/float64(n)*math.Ln2)))
+	nBits := max(64, uint64(m)) //nolint:mnd
+
+	return &bloomSeenSet[T]{
+		bits:   make([]uint64, (nBits+63)/64), //nolint:mnd
+		nBits:  nBits,
+		hashes: k,
+	}
+}
+
+// add records the value and returns false if it may have been recorded
+// already
+func (s *bloomSeenSet[T]) add(v T) bool {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%v", v)
+
+	h1 := h.Sum64()
+	h2 := (h1 >> 32) | (h1 << 32) | 1 //nolint:mnd
+
+	isNew := false
+
+	for i := range s.hashes {
+		bit := (h1 + uint64(i)*h2) % s.nBits
+		word, mask := bit/64, uint64(1)<<(bit%64) //nolint:mnd
+
+		if s.bits[word]&mask == 0 {
+			isNew = true
+			s.bits[word] |= mask
+		}
+	}
+
+	return isNew
+}
+
+// UniqueGen wraps a TypedGenerator and ensures that it never gives the same
+// value twice. Each time Next is called the wrapped TypedGenerator is moved
+// on repeatedly until it gives a value that has not been seen before. If no
+// such value is found within the retry limit the error is reported by the
+// Err method and subsequent values may repeat. It implements the
+// TypedGenerator and ErrReporter interfaces.
+type UniqueGen[T comparable] struct {
+	tg         TypedGenerator[T]
+	seen       seenSet[T]
+	maxRetries int
+	count      int
+	err        error
+}
+
+// UniqueGenOptFunc is the type of an option-setting function that will set
+// a value in a UniqueGen
+type UniqueGenOptFunc[T comparable] func(ug *UniqueGen[T]) error
+
+// UniqueGenSetMaxRetries returns a UniqueGen Opt function which sets the
+// number of times the wrapped TypedGenerator is moved on in search of an
+// unseen value before giving up. The default is 1000.
+func UniqueGenSetMaxRetries[T comparable](n int) UniqueGenOptFunc[T] {
+	return func(ug *UniqueGen[T]) error {
+		if n <= 0 {
+			return fmt.Errorf("the maximum retries (%d) must be > 0", n)
+		}
+
+		ug.maxRetries = n
+
+		return nil
+	}
+}
+
+// UniqueGenSetBloom returns a UniqueGen Opt function which makes the
+// UniqueGen record the values in a Bloom filter rather than a map. The
+// memory used is fixed, sized for the expected number of values n and the
+// false positive rate. A false positive causes an unseen value to be
+// rejected so the values are still unique but some values that could have
+// been generated will be skipped.
+func UniqueGenSetBloom[T comparable](
+	n int, fpRate float64,
+) UniqueGenOptFunc[T] {
+	return func(ug *UniqueGen[T]) error {
+		if n <= 0 {
+			return fmt.Errorf("the expected number of values (%d) must be > 0",
+				n)
+		}
+
+		if fpRate <= 0 || fpRate >= 1 {
+			return fmt.Errorf(
+				"the false positive rate (%g) must be between 0 and 1",
+				fpRate)
+		}
+
+		ug.seen = newBloomSeenSet[T](n, fpRate)
+
+		return nil
+	}
+}
+
+// NewUniqueGen creates a new UniqueGen wrapping the TypedGenerator. The
+// current value of the TypedGenerator is recorded as the first value. It
+// will panic if the TypedGenerator is nil or if any of the option functions
+// returns an error.
+func NewUniqueGen[T comparable](
+	tg TypedGenerator[T], opts ...UniqueGenOptFunc[T],
+) *UniqueGen[T] {
+	if tg == nil {
+		panic(errors.New("a nil TypedGenerator has been supplied"))
+	}
+
+	ug := &UniqueGen[T]{
+		tg:         tg,
+		maxRetries: dfltUniqueMaxRetries,
+	}
+
+	for _, o := range opts {
+		if err := o(ug); err != nil {
+			panic(err)
+		}
+	}
+
+	if ug.seen == nil {
+		ug.seen = mapSeenSet[T]{}
+	}
+
+	ug.seen.add(tg.Value())
+	ug.count = 1
+
+	return ug
+}
+
+// Generate returns the string form of the current value
+func (ug UniqueGen[T]) Generate() string {
+	return ug.tg.Generate()
+}
+
+// Value returns the current value
+func (ug UniqueGen[T]) Value() T {
+	return ug.tg.Value()
+}
+
+// Next moves the wrapped TypedGenerator on until it gives a value that has
+// not been seen before. If none is found within the retry limit the error
+// is recorded.
+func (ug *UniqueGen[T]) Next() {
+	for range ug.maxRetries {
+		ug.tg.Next()
+
+		if ug.seen.add(ug.tg.Value()) {
+			ug.count++
+			return
+		}
+	}
+
+	if ug.err == nil {
+		ug.err = fmt.Errorf("%w: none found in %d attempts"+
+			" after %d unique values",
+			ErrNoUniqueValue, ug.maxRetries, ug.count)
+	}
+}
+
+// Err returns the error recorded if no unique value could be found
+func (ug UniqueGen[T]) Err() error {
+	return ug.err
+}
@@ -0,0 +1,97 @@
+package datagen_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// smallRangeGen returns a random int generator giving values from 1 to 20
+func smallRangeGen() *datagen.Gen[int] {
+	return datagen.NewGen(
+		datagen.GenSetValue(10),
+		datagen.GenSetValSetter[int](
+			datagen.NewNormValSetter(1, 20, 10, 5,
+				datagen.NormValSetterSetSeeder[int](datagen.NewSeeder(42)))))
+}
+
+func TestUniqueGen(t *testing.T) {
+	testCases := []struct {
+		name string
+		ug   *datagen.UniqueGen[int]
+	}{
+		{
+			name: "map",
+			ug: datagen.NewUniqueGen[int](smallRangeGen(),
+				datagen.UniqueGenSetMaxRetries[int](100000)),
+		},
+		{
+			name: "bloom",
+			ug: datagen.NewUniqueGen[int](smallRangeGen(),
+				datagen.UniqueGenSetMaxRetries[int](100000),
+				datagen.UniqueGenSetBloom[int](20, 0.001)),
+		},
+	}
+
+	for _, tc := range testCases {
+		seen := map[int]bool{}
+
+		for range 15 {
+			v := tc.ug.Value()
+			if seen[v] {
+				t.Errorf("%s: %d was repeated", tc.name, v)
+			}
+
+			seen[v] = true
+
+			tc.ug.Next()
+		}
+
+		if err := tc.ug.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestUniqueGenExhausted(t *testing.T) {
+	ug := datagen.NewUniqueGen[int](smallRangeGen())
+	r := datagen.NewRecord("r", datagen.NewField("v", ug))
+
+	var buf bytes.Buffer
+
+	err := datagen.NewCSVWriter().Write(&buf, r, 21)
+	if !errors.Is(err, datagen.ErrNoUniqueValue) {
+		t.Errorf("expected ErrNoUniqueValue, got: %v", err)
+	}
+}
+
+func TestUniqueGenBadArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{name: "nil generator", f: func() { datagen.NewUniqueGen[int](nil) }},
+		{name: "retries 0", f: func() {
+			datagen.NewUniqueGen[int](smallRangeGen(),
+				datagen.UniqueGenSetMaxRetries[int](0))
+		}},
+		{name: "false positive rate 1", f: func() {
+			datagen.NewUniqueGen[int](smallRangeGen(),
+				datagen.UniqueGenSetBloom[int](10, 1))
+		}},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: a panic was expected", tc.name)
+				}
+			}()
+
+			tc.f()
+		}()
+	}
+}
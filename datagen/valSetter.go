@@ -1,6 +1,7 @@
 package datagen
 
 import (
+	"errors"
 	"math/rand/v2"
 
 	"golang.org/x/exp/constraints"
@@ -31,6 +32,13 @@ func (vs IncrementingValSetter[T]) SetVal(v *T) {
 	*v += vs.incr
 }
 
+// Nth returns the value that would be reached by calling SetVal n times,
+// starting from the supplied value. This can be used to give the initial
+// value of a shard of a dataset.
+func (vs IncrementingValSetter[T]) Nth(start T, n int) T {
+	return start + T(n)*vs.incr
+}
+
 // ===================================================================
 
 // NormValSetter implements a ValSetter that will set the passed value to a
@@ -41,18 +49,54 @@ type NormValSetter[T constraints.Integer | constraints.Float] struct {
 	mean, sd float64
 }
 
-// NewNormValSetter creates and returns a NormValSetter
+// NormValSetterOptFunc is the type of an option-setting function that will
+// set a value in a NormValSetter
+type NormValSetterOptFunc[T constraints.Integer | constraints.Float] func(
+	vs *NormValSetter[T],
+) error
+
+// NormValSetterSetSeeder returns a NormValSetter Opt function which sets the
+// random number generator to one taken from the supplied Seeder. This allows
+// the generated values to be reproduced.
+func NormValSetterSetSeeder[T constraints.Integer | constraints.Float](
+	s *Seeder,
+) NormValSetterOptFunc[T] {
+	return func(vs *NormValSetter[T]) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		vs.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// NewNormValSetter creates and returns a NormValSetter. It will panic if
+// any of the option functions returns an error.
 func NewNormValSetter[T constraints.Integer | constraints.Float](
 	minimum, maximum T,
 	mean, sd float64,
+	opts ...NormValSetterOptFunc[T],
 ) *NormValSetter[T] {
-	return &NormValSetter[T]{
-		r:    NewRand(),
+	vs := &NormValSetter[T]{
 		min:  minimum,
 		max:  maximum,
 		mean: mean,
 		sd:   sd,
 	}
+
+	for _, o := range opts {
+		if err := o(vs); err != nil {
+			panic(err)
+		}
+	}
+
+	if vs.r == nil {
+		vs.r = NewRand()
+	}
+
+	return vs
 }
 
 // SetVal increments the given value by the incr amount
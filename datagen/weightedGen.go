@@ -0,0 +1,332 @@
+package datagen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// ErrExhausted is the error reported by a generator drawing values without
+// replacement when every value has been drawn.
+var ErrExhausted = errors.New("all the values have been drawn")
+
+// WeightedVal records a value and an associated weight. When there are
+// multiple such entries the WeightedGen will generate values in the
+// appropriate proportions.
+type WeightedVal[T any] struct {
+	Val    T
+	Weight int
+}
+
+// wgWeightedVal holds the internal representation of a weighted value. It
+// includes the cumulative weight of all the prior weighted values and the
+// entries in the alias table used for random selection.
+type wgWeightedVal[T any] struct {
+	WeightedVal[T]
+	cumWeight int
+
+	aliasCut int
+	alias    int
+}
+
+// WeightedGen is used to generate values selected from a population of
+// weighted values. It implements the TypedGenerator interface so the
+// selected value can be used by other fields, through Value, as well as
+// being displayed, through Generate.
+//
+// The value is chosen when Next is called so Value and Generate take
+// constant time. Random values are chosen using Walker's alias method which
+// also takes constant time regardless of the number of values.
+//
+// For the Shuffled and NoReplacement modes each value occupies as many
+// slots as its weight, so the total weight should be kept to a size that
+// can be held in memory. In NoReplacement mode, once every slot has been
+// drawn the last value is repeated and the error is reported by the Err
+// method. It implements the ErrReporter interface.
+type WeightedGen[T any] struct {
+	seqOrRand seqOrRandType
+	r         *rand.Rand
+	idx       int
+	pos       int
+	sm        StringMaker[T]
+	err       error
+
+	vals      []wgWeightedVal[T]
+	totWeight int
+	slots     []int
+}
+
+// addWeightedVal adds the weighted value to the WeightedGen, calculating
+// the new total weight as it does so. It will return an error if the weight
+// is <= 0.
+func (wg *WeightedGen[T]) addWeightedVal(wv WeightedVal[T]) error {
+	if wv.Weight <= 0 {
+		return fmt.Errorf("the weight (%d) for value %v is <= 0",
+			wv.Weight, wv.Val)
+	}
+
+	wg.totWeight += wv.Weight
+	wg.vals = append(wg.vals,
+		wgWeightedVal[T]{
+			WeightedVal: wv,
+			cumWeight:   wg.totWeight,
+		})
+
+	return nil
+}
+
+// setVals sets the values in the WeightedGen. It will return an error if
+// the seqOrRand value is invalid, if no values are given or if any of the
+// weights is <= 0.
+func (wg *WeightedGen[T]) setVals(
+	seqOrRand seqOrRandType, vals []WeightedVal[T],
+) error {
+	if !seqOrRand.IsValid() {
+		return fmt.Errorf("bad seqOrRand value: %d", seqOrRand)
+	}
+
+	if len(vals) == 0 {
+		return errors.New("no weighted values have been given")
+	}
+
+	wg.seqOrRand = seqOrRand
+	wg.sm = dfltGenImpl[T]{}
+	wg.vals = make([]wgWeightedVal[T], 0, len(vals))
+
+	for _, wv := range vals {
+		if err := wg.addWeightedVal(wv); err != nil {
+			return err
+		}
+	}
+
+	wg.makeAliasTable()
+
+	return nil
+}
+
+// makeAliasTable constructs the alias table used to choose random values
+// (see Vose, "A Linear Algorithm For Generating Random Numbers With a Given
+// Distribution"). Each value is given a slot of size totWeight; a slot is
+// filled with as much of the value's scaled weight as will fit and the
+// remainder is made up from a value whose scaled weight exceeds the slot
+// size. Integer arithmetic is used so the proportions are exact.
+func (wg *WeightedGen[T]) makeAliasTable() {
+	n := len(wg.vals)
+	scaled := make([]int, n)
+
+	var small, large []int
+
+	for i, wv := range wg.vals {
+		scaled[i] = wv.Weight * n
+		if scaled[i] < wg.totWeight {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		wg.vals[s].aliasCut = scaled[s]
+		wg.vals[s].alias = l
+
+		scaled[l] -= wg.totWeight - scaled[s]
+		if scaled[l] < wg.totWeight {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// the remaining entries fill their slots exactly
+	for _, i := range large {
+		wg.vals[i].aliasCut = wg.totWeight
+		wg.vals[i].alias = i
+	}
+}
+
+// chooseRandom sets the position of the chosen value using the alias table
+func (wg *WeightedGen[T]) chooseRandom() {
+	wg.pos = wg.r.IntN(len(wg.vals))
+	if wv := wg.vals[wg.pos]; wg.r.IntN(wg.totWeight) >= wv.aliasCut {
+		wg.pos = wv.alias
+	}
+}
+
+// makeSlots populates the slots from which values are drawn without
+// replacement. Each value has as many slots as its weight.
+func (wg *WeightedGen[T]) makeSlots() {
+	wg.slots = make([]int, 0, wg.totWeight)
+
+	for i, wv := range wg.vals {
+		for range wv.Weight {
+			wg.slots = append(wg.slots, i)
+		}
+	}
+}
+
+// drawSlot chooses one of the slots not yet drawn in the current cycle and
+// moves it to the idx position. The drawn slots thus form a shuffled prefix
+// of the slots (this is an incremental Fisher-Yates shuffle).
+func (wg *WeightedGen[T]) drawSlot() {
+	j := wg.idx + wg.r.IntN(len(wg.slots)-wg.idx)
+	wg.slots[wg.idx], wg.slots[j] = wg.slots[j], wg.slots[wg.idx]
+	wg.pos = wg.slots[wg.idx]
+}
+
+// start chooses the first value. It should be called once the options have
+// been applied.
+func (wg *WeightedGen[T]) start() {
+	if wg.seqOrRand == Sequential {
+		return
+	}
+
+	if wg.r == nil {
+		wg.r = NewRand()
+	}
+
+	if wg.seqOrRand == Random {
+		wg.chooseRandom()
+		return
+	}
+
+	wg.makeSlots()
+	wg.drawSlot()
+}
+
+// WeightedGenOptFunc is the type of an option-setting function that will set
+// a value in a WeightedGen
+type WeightedGenOptFunc[T any] func(wg *WeightedGen[T]) error
+
+// WeightedGenSetSeeder returns a WeightedGen Opt function which sets the
+// random number generator to one taken from the supplied Seeder. This
+// allows the generated values to be reproduced.
+func WeightedGenSetSeeder[T any](s *Seeder) WeightedGenOptFunc[T] {
+	return func(wg *WeightedGen[T]) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		wg.r = s.NewRand()
+
+		return nil
+	}
+}
+
+// WeightedGenSetStringMaker returns a WeightedGen Opt function which sets
+// the StringMaker used to generate the string form of the selected value.
+// The default gives the Go string representation of the value.
+func WeightedGenSetStringMaker[T any](sm StringMaker[T]) WeightedGenOptFunc[T] {
+	return func(wg *WeightedGen[T]) error {
+		if sm == nil {
+			return errors.New("a nil string maker has been supplied")
+		}
+
+		wg.sm = sm
+
+		return nil
+	}
+}
+
+// NewWeightedGen creates a new WeightedGen object and returns it. It will
+// panic if no values are given, if any of the weights is <= 0, if the
+// seqOrRand value is invalid or if any of the option functions returns an
+// error.
+//
+// The seqOrRand value can be set to Random to cause the values to be chosen
+// randomly from the supplied values. Or it can be set to Sequential and the
+// values are returned in the order supplied. Shuffled and NoReplacement
+// draw the values at random without replacement. In all cases the values
+// are returned in proportions reflecting the weights.
+func NewWeightedGen[T any](seqOrRand seqOrRandType,
+	vals []WeightedVal[T], opts ...WeightedGenOptFunc[T],
+) *WeightedGen[T] {
+	wg, err := newWeightedGen(seqOrRand, vals, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return wg
+}
+
+// newWeightedGen creates a new WeightedGen object and returns it. It
+// returns an error rather than panicking if the values or the options are
+// bad.
+func newWeightedGen[T any](seqOrRand seqOrRandType,
+	vals []WeightedVal[T], opts ...WeightedGenOptFunc[T],
+) (*WeightedGen[T], error) {
+	wg := &WeightedGen[T]{}
+	if err := wg.setVals(seqOrRand, vals); err != nil {
+		return nil, err
+	}
+
+	for _, o := range opts {
+		if err := o(wg); err != nil {
+			return nil, err
+		}
+	}
+
+	wg.start()
+
+	return wg, nil
+}
+
+// Next moves the value to its next value
+func (wg *WeightedGen[T]) Next() {
+	switch wg.seqOrRand {
+	case Random:
+		wg.chooseRandom()
+		return
+	case Shuffled, NoReplacement:
+		wg.nextSlot()
+		return
+	}
+
+	wg.idx++
+	if wg.idx >= wg.totWeight {
+		wg.idx = 0
+		wg.pos = 0
+	} else if wg.idx >= wg.vals[wg.pos].cumWeight {
+		wg.pos++
+	}
+}
+
+// nextSlot draws the next slot. When all the slots have been drawn a
+// Shuffled WeightedGen starts a new cycle while a NoReplacement
+// WeightedGen records the error and leaves the value unchanged.
+func (wg *WeightedGen[T]) nextSlot() {
+	if wg.idx+1 < len(wg.slots) {
+		wg.idx++
+	} else if wg.seqOrRand == Shuffled {
+		wg.idx = 0
+	} else {
+		if wg.err == nil {
+			wg.err = fmt.Errorf("%w after %d draws",
+				ErrExhausted, len(wg.slots))
+		}
+
+		return
+	}
+
+	wg.drawSlot()
+}
+
+// Value returns the selected value
+func (wg WeightedGen[T]) Value() T {
+	return wg.vals[wg.pos].Val
+}
+
+// Generate returns the string form of the selected value
+func (wg WeightedGen[T]) Generate() string {
+	return wg.sm.MakeString(wg.Value())
+}
+
+// Err returns the error recorded if a NoReplacement WeightedGen has no
+// values left to draw
+func (wg WeightedGen[T]) Err() error {
+	return wg.err
+}
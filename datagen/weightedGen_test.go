@@ -0,0 +1,281 @@
+package datagen_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+// countryCode is a StringMaker giving the country's ISO 3166 code
+type countryCode struct{}
+
+func (countryCode) MakeString(c datagen.Country) string { return c.Code() }
+
+func TestWeightedGenSequential(t *testing.T) {
+	wg := datagen.NewWeightedGen(datagen.Sequential,
+		[]datagen.WeightedVal[datagen.Country]{
+			{Val: datagen.Countries["US"], Weight: 2},
+			{Val: datagen.Countries["GB"], Weight: 1},
+		},
+		datagen.WeightedGenSetStringMaker[datagen.Country](countryCode{}))
+
+	var codes []string
+
+	for range 6 {
+		if wg.Value().Code() != wg.Generate() {
+			t.Errorf("the Value (%v) doesn't match the string (%q)",
+				wg.Value(), wg.Generate())
+		}
+
+		codes = append(codes, wg.Generate())
+		wg.Next()
+	}
+
+	exp := []string{"US", "US", "GB", "US", "US", "GB"}
+	if !slices.Equal(codes, exp) {
+		t.Errorf("bad codes\nexpected: %v\n     got: %v", exp, codes)
+	}
+}
+
+func TestWeightedGenRandomReproducible(t *testing.T) {
+	vals := []datagen.WeightedVal[int]{
+		{Val: 200, Weight: 90},
+		{Val: 404, Weight: 8},
+		{Val: 500, Weight: 2},
+	}
+
+	draw := func(seed uint64) []int {
+		wg := datagen.NewWeightedGen(datagen.Random, vals,
+			datagen.WeightedGenSetSeeder[int](datagen.NewSeeder(seed)))
+
+		var got []int
+
+		for range 50 {
+			got = append(got, wg.Value())
+			wg.Next()
+		}
+
+		return got
+	}
+
+	if a, b := draw(42), draw(42); !slices.Equal(a, b) {
+		t.Errorf("the same seed gave different values:\n%v\n%v", a, b)
+	}
+}
+
+func TestWeightedGenBadArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{name: "no values", f: func() {
+			datagen.NewWeightedGen[int](datagen.Random, nil)
+		}},
+		{name: "zero weight", f: func() {
+			datagen.NewWeightedGen(datagen.Random,
+				[]datagen.WeightedVal[int]{{Val: 1, Weight: 0}})
+		}},
+		{name: "nil string maker", f: func() {
+			datagen.NewWeightedGen(datagen.Random,
+				[]datagen.WeightedVal[int]{{Val: 1, Weight: 1}},
+				datagen.WeightedGenSetStringMaker[int](nil))
+		}},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: a panic was expected", tc.name)
+				}
+			}()
+
+			tc.f()
+		}()
+	}
+}
+
+// TestWeightedGenFrequencies checks that the values chosen at random using
+// the alias table appear in proportion to their weights
+func TestWeightedGenFrequencies(t *testing.T) {
+	const draws = 200000
+
+	vals := []datagen.WeightedVal[int]{
+		{Val: 0, Weight: 1},
+		{Val: 1, Weight: 7},
+		{Val: 2, Weight: 2},
+		{Val: 3, Weight: 30},
+		{Val: 4, Weight: 10},
+	}
+	totWeight := 0.0
+
+	for _, wv := range vals {
+		totWeight += float64(wv.Weight)
+	}
+
+	wg := datagen.NewWeightedGen(datagen.Random, vals,
+		datagen.WeightedGenSetSeeder[int](datagen.NewSeeder(42)))
+	counts := make([]int, len(vals))
+
+	for range draws {
+		counts[wg.Value()]++
+		wg.Next()
+	}
+
+	for i, wv := range vals {
+		p := float64(wv.Weight) / totWeight
+		sd := math.Sqrt(draws * p * (1 - p))
+
+		if diff := math.Abs(float64(counts[i]) - draws*p); diff > 4*sd {
+			t.Errorf("value %d: expected about %.0f, got %d",
+				wv.Val, draws*p, counts[i])
+		}
+	}
+}
+
+// linearWeightedGen chooses weighted values by scanning the cumulative
+// weights, as the WStringGen used to. It is used as the baseline for the
+// benchmarks.
+type linearWeightedGen struct {
+	vals      []int
+	cumWeight []int
+	r         *rand.Rand
+	val       int
+}
+
+func newLinearWeightedGen(vals []datagen.WeightedVal[int]) *linearWeightedGen {
+	lg := &linearWeightedGen{r: datagen.NewSeeder(42).NewRand()}
+	tot := 0
+
+	for _, wv := range vals {
+		tot += wv.Weight
+		lg.vals = append(lg.vals, wv.Val)
+		lg.cumWeight = append(lg.cumWeight, tot)
+	}
+
+	lg.Next()
+
+	return lg
+}
+
+func (lg *linearWeightedGen) Next() {
+	target := lg.r.IntN(lg.cumWeight[len(lg.cumWeight)-1])
+
+	for i, cw := range lg.cumWeight {
+		if cw > target {
+			lg.val = lg.vals[i]
+			return
+		}
+	}
+}
+
+func (lg *linearWeightedGen) Value() int { return lg.val }
+
+// benchWeightedVals returns 100k values with varied weights
+func benchWeightedVals() []datagen.WeightedVal[int] {
+	vals := make([]datagen.WeightedVal[int], 100000)
+	for i := range vals {
+		vals[i] = datagen.WeightedVal[int]{Val: i, Weight: 1 + i%97}
+	}
+
+	return vals
+}
+
+func BenchmarkWeightedGen(b *testing.B) {
+	vals := benchWeightedVals()
+
+	b.Run("linear", func(b *testing.B) {
+		lg := newLinearWeightedGen(vals)
+
+		for b.Loop() {
+			lg.Next()
+			_ = lg.Value()
+		}
+	})
+
+	b.Run("alias", func(b *testing.B) {
+		wg := datagen.NewWeightedGen(datagen.Random, vals,
+			datagen.WeightedGenSetSeeder[int](datagen.NewSeeder(42)))
+
+		for b.Loop() {
+			wg.Next()
+			_ = wg.Value()
+		}
+	})
+}
+
+// drawInts returns the next n values from the WeightedGen
+func drawInts(wg *datagen.WeightedGen[int], n int) []int {
+	var got []int
+
+	for range n {
+		got = append(got, wg.Value())
+		wg.Next()
+	}
+
+	return got
+}
+
+func TestWeightedGenShuffled(t *testing.T) {
+	vals := []datagen.WeightedVal[int]{
+		{Val: 1, Weight: 1},
+		{Val: 2, Weight: 2},
+		{Val: 3, Weight: 3},
+	}
+	wg := datagen.NewWeightedGen(datagen.Shuffled, vals,
+		datagen.WeightedGenSetSeeder[int](datagen.NewSeeder(42)))
+
+	exp := []int{1, 2, 2, 3, 3, 3}
+	cycles := map[string]bool{}
+
+	for range 20 {
+		cycle := drawInts(wg, 6)
+		cycles[fmt.Sprint(cycle)] = true
+
+		slices.Sort(cycle)
+
+		if !slices.Equal(cycle, exp) {
+			t.Errorf("bad cycle\nexpected: %v\n     got: %v", exp, cycle)
+		}
+	}
+
+	if len(cycles) == 1 {
+		t.Error("the values were not reshuffled between cycles")
+	}
+
+	if err := wg.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWeightedGenNoReplacement(t *testing.T) {
+	vals := []datagen.WeightedVal[int]{
+		{Val: 1, Weight: 2},
+		{Val: 2, Weight: 3},
+	}
+	wg := datagen.NewWeightedGen(datagen.NoReplacement, vals,
+		datagen.WeightedGenSetSeeder[int](datagen.NewSeeder(42)))
+
+	got := drawInts(wg, 4)
+	if err := wg.Err(); err != nil {
+		t.Errorf("unexpected error before the values ran out: %v", err)
+	}
+
+	got = append(got, wg.Value())
+	wg.Next()
+
+	slices.Sort(got)
+
+	if exp := []int{1, 1, 2, 2, 2}; !slices.Equal(got, exp) {
+		t.Errorf("bad values\nexpected: %v\n     got: %v", exp, got)
+	}
+
+	if err := wg.Err(); !errors.Is(err, datagen.ErrExhausted) {
+		t.Errorf("expected ErrExhausted, got: %v", err)
+	}
+}
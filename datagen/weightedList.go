@@ -0,0 +1,284 @@
+package datagen
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// WeightedListFormat describes the layout of a file of weighted values
+type WeightedListFormat int
+
+// These constants represent the supported layouts of a file of weighted
+// values. In each case there is one value per line followed by its weight.
+// Blank lines and lines starting with '#' are ignored.
+const (
+	// WeightedListCSV has the value and weight as comma-separated fields,
+	// quoted as necessary (see encoding/csv)
+	WeightedListCSV WeightedListFormat = iota
+	// WeightedListTSV has the value and weight separated by a tab
+	WeightedListTSV
+)
+
+// WeightedListFormatFor returns the format suggested by the file name. A
+// name ending in ".tsv" or ".tab" gives WeightedListTSV, any other name
+// gives WeightedListCSV.
+func WeightedListFormatFor(name string) WeightedListFormat {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".tsv", ".tab":
+		return WeightedListTSV
+	}
+
+	return WeightedListCSV
+}
+
+// WeightedListReader reads lists of weighted values. Problems in the list,
+// such as missing fields or weights which are not positive integers, are
+// reported as errors giving the line number.
+type WeightedListReader struct {
+	format    WeightedListFormat
+	hasHeader bool
+}
+
+// WeightedListReaderOptFunc is the type of an option-setting function that
+// will set a value in a WeightedListReader
+type WeightedListReaderOptFunc func(wlr *WeightedListReader) error
+
+// WeightedListReaderSetHeader returns a WeightedListReader Opt function
+// which makes the first line (ignoring blank and comment lines) be treated
+// as a header and skipped.
+func WeightedListReaderSetHeader() WeightedListReaderOptFunc {
+	return func(wlr *WeightedListReader) error {
+		wlr.hasHeader = true
+		return nil
+	}
+}
+
+// NewWeightedListReader creates a new WeightedListReader for lists in the
+// given format. It will panic if the format is unknown or if any of the
+// option functions returns an error.
+func NewWeightedListReader(
+	format WeightedListFormat, opts ...WeightedListReaderOptFunc,
+) *WeightedListReader {
+	if format != WeightedListCSV && format != WeightedListTSV {
+		panic(fmt.Errorf("unknown weighted list format: %d", format))
+	}
+
+	wlr := &WeightedListReader{format: format}
+
+	for _, o := range opts {
+		if err := o(wlr); err != nil {
+			panic(err)
+		}
+	}
+
+	return wlr
+}
+
+// rawEntry records the unparsed value and weight and the line they were
+// read from
+type rawEntry struct {
+	line   int
+	val    string
+	weight string
+}
+
+// readCSV reads the raw entries from CSV-formatted lines
+func (wlr *WeightedListReader) readCSV(rd io.Reader) ([]rawEntry, error) {
+	cr := csv.NewReader(rd)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 2
+
+	var entries []rawEntry
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		entries = append(entries,
+			rawEntry{line: line, val: rec[0], weight: rec[1]})
+	}
+}
+
+// readTSV reads the raw entries from tab-separated lines
+func (wlr *WeightedListReader) readTSV(rd io.Reader) ([]rawEntry, error) {
+	scanner := bufio.NewScanner(rd)
+
+	var entries []rawEntry
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.Split(text, "\t")
+		if len(parts) != 2 { //nolint:mnd
+			return nil, fmt.Errorf(
+				"line %d: expected a value and a weight separated by a tab,"+
+					" found %d fields",
+				line, len(parts))
+		}
+
+		entries = append(entries,
+			rawEntry{line: line, val: parts[0], weight: parts[1]})
+	}
+
+	return entries, scanner.Err()
+}
+
+// ReadWeightedVals reads weighted values from the reader using the
+// WeightedListReader. The parse function converts the text of each value
+// into a value of the required type. An error is returned if the list
+// cannot be read, if any value cannot be parsed, if any weight is not a
+// positive integer or if the list is empty.
+func ReadWeightedVals[T any](wlr *WeightedListReader, rd io.Reader,
+	parse func(string) (T, error),
+) ([]WeightedVal[T], error) {
+	var (
+		entries []rawEntry
+		err     error
+	)
+
+	if wlr.format == WeightedListTSV {
+		entries, err = wlr.readTSV(rd)
+	} else {
+		entries, err = wlr.readCSV(rd)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if wlr.hasHeader && len(entries) > 0 {
+		entries = entries[1:]
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("no weighted values were found")
+	}
+
+	vals := make([]WeightedVal[T], 0, len(entries))
+
+	for _, e := range entries {
+		w, err := strconv.Atoi(strings.TrimSpace(e.weight))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"line %d: the weight (%q) for %q is not an integer",
+				e.line, e.weight, e.val)
+		}
+
+		if w <= 0 {
+			return nil, fmt.Errorf("line %d: the weight (%d) for %q is <= 0",
+				e.line, w, e.val)
+		}
+
+		v, err := parse(e.val)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad value %q: %w",
+				e.line, e.val, err)
+		}
+
+		vals = append(vals, WeightedVal[T]{Val: v, Weight: w})
+	}
+
+	return vals, nil
+}
+
+// ReadStrings reads weighted strings from the reader. Errors are as for
+// ReadWeightedVals.
+func (wlr *WeightedListReader) ReadStrings(rd io.Reader) (
+	[]WeightedString, error,
+) {
+	vals, err := ReadWeightedVals(wlr, rd,
+		func(s string) (string, error) { return s, nil })
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make([]WeightedString, 0, len(vals))
+	for _, v := range vals {
+		strs = append(strs, WeightedString{Str: v.Val, Weight: v.Weight})
+	}
+
+	return strs, nil
+}
+
+// ReadStringsFromFS reads weighted strings from the named file in the file
+// system. Any error is prefixed with the file name.
+func (wlr *WeightedListReader) ReadStringsFromFS(fsys fs.FS, name string) (
+	[]WeightedString, error,
+) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	strs, err := wlr.ReadStrings(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return strs, nil
+}
+
+// NewWeightedGenFromReader creates a new WeightedGen from the weighted
+// values read from the reader (see ReadWeightedVals). Unlike NewWeightedGen
+// it returns an error rather than panicking if the values or the options
+// are bad.
+func NewWeightedGenFromReader[T any](seqOrRand seqOrRandType,
+	wlr *WeightedListReader, rd io.Reader, parse func(string) (T, error),
+	opts ...WeightedGenOptFunc[T],
+) (*WeightedGen[T], error) {
+	vals, err := ReadWeightedVals(wlr, rd, parse)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWeightedGen(seqOrRand, vals, opts...)
+}
+
+// NewWStringGenFromReader creates a new WStringGen from the weighted
+// strings read from the reader. Unlike NewWStringGen it returns an error
+// rather than panicking if the strings or the options are bad.
+func NewWStringGenFromReader(seqOrRand seqOrRandType,
+	wlr *WeightedListReader, rd io.Reader, opts ...WStringGenOptFunc,
+) (*WStringGen, error) {
+	strs, err := wlr.ReadStrings(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWStringGen(seqOrRand, strs, opts...)
+}
+
+// NewWStringGenFromFS creates a new WStringGen from the weighted strings
+// read from the named file in the file system. WeightedListFormatFor can be
+// used to choose the format of the WeightedListReader from the file name.
+// Unlike NewWStringGen it returns an error rather than panicking if the
+// file cannot be read or if the strings or the options are bad.
+func NewWStringGenFromFS(seqOrRand seqOrRandType,
+	wlr *WeightedListReader, fsys fs.FS, name string,
+	opts ...WStringGenOptFunc,
+) (*WStringGen, error) {
+	strs, err := wlr.ReadStringsFromFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWStringGen(seqOrRand, strs, opts...)
+}
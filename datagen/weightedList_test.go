@@ -0,0 +1,150 @@
+package datagen_test
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+func TestReadStrings(t *testing.T) {
+	testCases := []struct {
+		name   string
+		wlr    *datagen.WeightedListReader
+		text   string
+		expErr string
+		expVal []datagen.WeightedString
+	}{
+		{
+			name: "CSV with header and comments",
+			wlr: datagen.NewWeightedListReader(datagen.WeightedListCSV,
+				datagen.WeightedListReaderSetHeader()),
+			text: "name,count\n# a comment\n\nSmith,10\n\"Jones, Jr\", 3\n",
+			expVal: []datagen.WeightedString{
+				{Str: "Smith", Weight: 10},
+				{Str: "Jones, Jr", Weight: 3},
+			},
+		},
+		{
+			name: "TSV",
+			wlr:  datagen.NewWeightedListReader(datagen.WeightedListTSV),
+			text: "# surnames\nSmith\t10\r\nO'Brien\t2\n",
+			expVal: []datagen.WeightedString{
+				{Str: "Smith", Weight: 10},
+				{Str: "O'Brien", Weight: 2},
+			},
+		},
+		{
+			name:   "bad weight",
+			wlr:    datagen.NewWeightedListReader(datagen.WeightedListCSV),
+			text:   "Smith,10\nJones,lots\n",
+			expErr: `line 2: the weight ("lots") for "Jones" is not an integer`,
+		},
+		{
+			name:   "zero weight",
+			wlr:    datagen.NewWeightedListReader(datagen.WeightedListTSV),
+			text:   "Smith\t10\n\nJones\t0\n",
+			expErr: `line 3: the weight (0) for "Jones" is <= 0`,
+		},
+		{
+			name:   "missing weight",
+			wlr:    datagen.NewWeightedListReader(datagen.WeightedListTSV),
+			text:   "Smith\n",
+			expErr: "line 1: expected a value and a weight",
+		},
+		{
+			name:   "empty",
+			wlr:    datagen.NewWeightedListReader(datagen.WeightedListCSV),
+			text:   "# nothing\n",
+			expErr: "no weighted values were found",
+		},
+	}
+
+	for _, tc := range testCases {
+		strs, err := tc.wlr.ReadStrings(strings.NewReader(tc.text))
+
+		if tc.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+				t.Errorf("%s: the error should contain %q, got: %v",
+					tc.name, tc.expErr, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if !slices.Equal(strs, tc.expVal) {
+			t.Errorf("%s: bad strings\nexpected: %v\n     got: %v",
+				tc.name, tc.expVal, strs)
+		}
+	}
+}
+
+func TestNewWeightedGenFromReader(t *testing.T) {
+	wlr := datagen.NewWeightedListReader(datagen.WeightedListCSV)
+
+	wg, err := datagen.NewWeightedGenFromReader(datagen.Sequential, wlr,
+		strings.NewReader("1m,1\n90s,2\n"), time.ParseDuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []time.Duration
+
+	for range 3 {
+		got = append(got, wg.Value())
+		wg.Next()
+	}
+
+	exp := []time.Duration{time.Minute, 90 * time.Second, 90 * time.Second}
+	if !slices.Equal(got, exp) {
+		t.Errorf("bad values\nexpected: %v\n     got: %v", exp, got)
+	}
+
+	_, err = datagen.NewWeightedGenFromReader(datagen.Random, wlr,
+		strings.NewReader("1,1\nx,1\n"), strconv.Atoi)
+	if err == nil || !strings.Contains(err.Error(), `line 2: bad value "x"`) {
+		t.Errorf("a bad value error was expected, got: %v", err)
+	}
+}
+
+func TestNewWStringGenFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"names.tsv": &fstest.MapFile{Data: []byte("Ann\t1\nBob\t1\n")},
+		"bad.csv":   &fstest.MapFile{Data: []byte("Ann,-1\n")},
+	}
+
+	for _, name := range []string{"names.tsv", "bad.csv"} {
+		sg, err := datagen.NewWStringGenFromFS(datagen.Sequential,
+			datagen.NewWeightedListReader(
+				datagen.WeightedListFormatFor(name)),
+			fsys, name)
+
+		if name == "bad.csv" {
+			if err == nil || !strings.HasPrefix(err.Error(), "bad.csv: line 1:") {
+				t.Errorf("%s: an error naming the file and line was expected,"+
+					" got: %v", name, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+
+		if got := sg.Generate(); got != "Ann" {
+			t.Errorf("%s: expected the first value to be Ann, got %q",
+				name, got)
+		}
+	}
+}
@@ -1,8 +1,7 @@
 package datagen
 
 import (
-	"fmt"
-	"math/rand/v2"
+	"errors"
 )
 
 // WeightedString records a string and an associated weight. When there are
@@ -13,99 +12,89 @@ type WeightedString struct {
 	Weight int
 }
 
-// sgWeightedString holds the internal representation of a weighted
-// string. It includes the cumulative weight of all the prior weighted
-// strings.
-type sgWeightedString struct {
-	WeightedString
-	cumWeight int
-}
-
 // WStringGen is used to generate strings selected from a population of
-// weighted values.
+// weighted values. It is a WeightedGen of strings.
 type WStringGen struct {
-	seqOrRand seqOrRandType
-	r         *rand.Rand
-	idx       int
-
-	strings   []sgWeightedString
-	totWeight int
+	WeightedGen[string]
 }
 
-// addWeightedString adds the weighted string to the WStringGen, calculating
-// the new total weight as it does so. It will panic if the weight is <= 0.
-func (sg *WStringGen) addWeightedString(ws WeightedString) {
-	if ws.Weight <= 0 {
-		panic(fmt.Sprintf(
-			"The weight (%d) for string %q is <= 0",
-			ws.Weight, ws.Str))
-	}
+// WStringGenOptFunc is the type of an option-setting function that will set
+// a value in a WStringGen
+type WStringGenOptFunc func(sg *WStringGen) error
+
+// WStringGenSetSeeder returns a WStringGen Opt function which sets the random
+// number generator to one taken from the supplied Seeder. This allows the
+// generated strings to be reproduced.
+func WStringGenSetSeeder(s *Seeder) WStringGenOptFunc {
+	return func(sg *WStringGen) error {
+		if s == nil {
+			return errors.New("a nil Seeder has been supplied")
+		}
+
+		sg.r = s.NewRand()
 
-	sg.totWeight += ws.Weight
-	sg.strings = append(sg.strings,
-		sgWeightedString{
-			WeightedString: ws,
-			cumWeight:      sg.totWeight,
-		})
+		return nil
+	}
 }
 
 // NewWStringGen creates a new WStringGen object and returns it. It will panic
-// if any of the weights is <= 0.
+// if any of the weights is <= 0 or if the seqOrRand value is invalid.
 //
 // The seqOrRand value can be set to Random to cause the values to be chosen
 // randomly from the supplied values. Or it can be set to Sequential and the
-// values are returned in the order supplied. In either case the values are
-// returned in proportions reflecting the weights.
+// values are returned in the order supplied. Shuffled and NoReplacement
+// draw the values at random without replacement (see WeightedGen). In all
+// cases the values are returned in proportions reflecting the weights.
 func NewWStringGen(seqOrRand seqOrRandType,
 	ws WeightedString, strs ...WeightedString,
 ) *WStringGen {
-	sg := &WStringGen{
-		seqOrRand: seqOrRand,
-		strings:   make([]sgWeightedString, 0, len(strs)+1),
-	}
-
-	sg.addWeightedString(ws)
+	return NewWStringGenWithOpts(seqOrRand,
+		append([]WeightedString{ws}, strs...))
+}
 
-	for _, ws := range strs {
-		sg.addWeightedString(ws)
+// NewWStringGenWithOpts creates a new WStringGen object and returns it. It
+// differs from NewWStringGen in that the weighted strings are passed as a
+// slice, leaving the trailing parameters free for option functions. It will
+// panic if no strings are given, if any of the weights is <= 0, if the
+// seqOrRand value is invalid or if any of the option functions returns an
+// error.
+func NewWStringGenWithOpts(seqOrRand seqOrRandType,
+	strs []WeightedString, opts ...WStringGenOptFunc,
+) *WStringGen {
+	sg, err := newWStringGen(seqOrRand, strs, opts...)
+	if err != nil {
+		panic(err)
 	}
 
-	if sg.totWeight == 0 {
-		return sg
-	}
+	return sg
+}
 
-	if seqOrRand == Random {
-		sg.r = NewRand()
-		sg.idx = sg.r.IntN(sg.totWeight)
+// newWStringGen creates a new WStringGen object and returns it. It returns
+// an error rather than panicking if the strings or the options are bad.
+func newWStringGen(seqOrRand seqOrRandType,
+	strs []WeightedString, opts ...WStringGenOptFunc,
+) (*WStringGen, error) {
+	if len(strs) == 0 {
+		return nil, errors.New("no weighted strings have been given")
 	}
 
-	return sg
-}
+	vals := make([]WeightedVal[string], 0, len(strs))
+	for _, ws := range strs {
+		vals = append(vals, WeightedVal[string]{Val: ws.Str, Weight: ws.Weight})
+	}
 
-// Next moves the string to it's next value
-func (sg *WStringGen) Next() {
-	if sg.seqOrRand == Random {
-		sg.idx = sg.r.IntN(sg.totWeight)
-	} else {
-		sg.idx++
-		if sg.idx >= sg.totWeight {
-			sg.idx = 0
-		}
+	sg := &WStringGen{}
+	if err := sg.setVals(seqOrRand, vals); err != nil {
+		return nil, err
 	}
-}
 
-// Generate returns the next string
-func (sg WStringGen) Generate() string {
-	for _, ws := range sg.strings {
-		if sg.idx < ws.cumWeight {
-			return ws.Str
+	for _, o := range opts {
+		if err := o(sg); err != nil {
+			return nil, err
 		}
 	}
 
-	return ""
-}
+	sg.start()
 
-// Value returns the next string
-func (sg WStringGen) Value() string {
-	return sg.Generate()
+	return sg, nil
 }
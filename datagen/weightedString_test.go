@@ -0,0 +1,30 @@
+package datagen_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nickwells/datagen.mod/datagen"
+)
+
+func TestWStringGen(t *testing.T) {
+	sg := datagen.NewWStringGen(datagen.Sequential,
+		datagen.WeightedString{Str: "a", Weight: 1},
+		datagen.WeightedString{Str: "b", Weight: 2})
+
+	var got []string
+
+	for range 5 {
+		if sg.Value() != sg.Generate() {
+			t.Errorf("the Value (%q) differs from the string (%q)",
+				sg.Value(), sg.Generate())
+		}
+
+		got = append(got, sg.Generate())
+		sg.Next()
+	}
+
+	if exp := []string{"a", "b", "b", "a", "b"}; !slices.Equal(got, exp) {
+		t.Errorf("bad strings\nexpected: %v\n     got: %v", exp, got)
+	}
+}